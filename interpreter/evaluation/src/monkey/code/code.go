@@ -0,0 +1,270 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions is a flat slice of bytes holding opcodes followed by their operands
+type Instructions []byte
+
+// String returns a human readable disassembly of the instructions
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+// fmtInstruction formats a single instruction with its operands
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+// Opcode is the first byte of every instruction
+type Opcode byte
+
+// Opcodes understood by the VM, assigned incrementally using iota
+const (
+	OpConstant Opcode = iota
+	OpPop
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+	OpGreaterEqual
+	OpLessEqual
+
+	OpMinus
+	OpBang
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpJumpNotTruthy
+	OpJumpNull
+	OpJump
+	OpJumpIfArgument
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetFree
+	OpSetFree
+	OpCaptureLocal
+	OpCaptureFree
+	OpCurrentClosure
+	OpGetBuiltin
+
+	OpArray
+	OpHash
+	OpTemplate
+	OpIndex
+	OpSlice
+	OpSetIndex
+	OpDup2
+
+	OpIterator
+	OpIterNext
+
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpClosure
+
+	OpSetupCatch
+	OpSetupFinally
+	OpPopHandler
+	OpThrow
+)
+
+// Definition describes an Opcode's readable name and the byte width of each of its operands
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpPow:          {"OpPow", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJump:          {"OpJump", []int{2}},
+	// jumps to the operand if the value on top of the stack is null, leaving it in place
+	OpJumpNull: {"OpJumpNull", []int{2}},
+	// jumps to the second operand if the current call was given the argument at the first
+	OpJumpIfArgument: {"OpJumpIfArgument", []int{1, 2}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{1}},
+	OpSetLocal:  {"OpSetLocal", []int{1}},
+	OpGetFree:   {"OpGetFree", []int{1}},
+	OpSetFree:   {"OpSetFree", []int{1}},
+	// pushes the cell a local is shared through with closures, moving the local into a new one the first time
+	OpCaptureLocal: {"OpCaptureLocal", []int{1}},
+	// pushes the cell of a free variable, to share it with a nested closure
+	OpCaptureFree:    {"OpCaptureFree", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+	// pushes the builtin at the operand's index of evaluator.Builtins
+	OpGetBuiltin: {"OpGetBuiltin", []int{1}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	// pops the operand's number of values and pushes the string joining their Inspect representations
+	OpTemplate: {"OpTemplate", []int{2}},
+	OpIndex:    {"OpIndex", []int{}},
+	// pops a step, an end, a start and a collection, null bounds are omitted ones, and pushes the slice
+	OpSlice: {"OpSlice", []int{}},
+	// pops a value, an index and a collection, stores the value at the index and pushes it back
+	OpSetIndex: {"OpSetIndex", []int{}},
+	// pushes copies of the top two stack elements, keeping their order
+	OpDup2: {"OpDup2", []int{}},
+
+	// replaces the value on top of the stack with an iterator over it
+	OpIterator: {"OpIterator", []int{}},
+	// pops an iterator and pushes its next value, or jumps to the operand once it is exhausted
+	OpIterNext: {"OpIterNext", []int{2}},
+
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}},
+
+	// installs a handler that, on an error, restores the stack and jumps to the operand with the caught error's hash pushed
+	OpSetupCatch: {"OpSetupCatch", []int{2}},
+	// installs a handler that, on an error, restores the stack and jumps to the operand with the error itself pushed
+	OpSetupFinally: {"OpSetupFinally", []int{2}},
+	// removes the most recently installed handler
+	OpPopHandler: {"OpPopHandler", []int{}},
+	// pops a value and raises it as an error, an error pushed by OpSetupFinally is raised again as is
+	OpThrow: {"OpThrow", []int{}},
+}
+
+// Lookup returns the Definition for the given opcode byte
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make encodes an opcode and its operands into a single instruction
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction and returns them with the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16 decodes a big endian two byte operand
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 decodes a one byte operand
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
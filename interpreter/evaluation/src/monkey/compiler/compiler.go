@@ -0,0 +1,1014 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/code"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+)
+
+// infixOpcodes maps infix operators to the opcode that applies them
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+	">=": code.OpGreaterEqual,
+	"<=": code.OpLessEqual,
+}
+
+// prefixOpcodes maps prefix operators to the opcode that applies them
+var prefixOpcodes = map[string]code.Opcode{
+	"-": code.OpMinus,
+	"!": code.OpBang,
+}
+
+// operandNames says what each operand of an opcode is, for the error when one doesn't fit its width
+var operandNames = map[code.Opcode][]string{
+	code.OpConstant:       {"constant index"},
+	code.OpClosure:        {"constant index", "free variable count"},
+	code.OpGetGlobal:      {"global index"},
+	code.OpSetGlobal:      {"global index"},
+	code.OpGetLocal:       {"local index"},
+	code.OpSetLocal:       {"local index"},
+	code.OpCaptureLocal:   {"local index"},
+	code.OpGetFree:        {"free variable index"},
+	code.OpSetFree:        {"free variable index"},
+	code.OpCaptureFree:    {"free variable index"},
+	code.OpCall:           {"argument count"},
+	code.OpArray:          {"array length"},
+	code.OpHash:           {"hash length"},
+	code.OpTemplate:       {"template part count"},
+	code.OpJump:           {"jump target"},
+	code.OpJumpNotTruthy:  {"jump target"},
+	code.OpJumpNull:       {"jump target"},
+	code.OpJumpIfArgument: {"parameter index", "jump target"},
+	code.OpIterNext:       {"jump target"},
+	code.OpSetupCatch:     {"jump target"},
+	code.OpSetupFinally:   {"jump target"},
+}
+
+// EmittedInstruction remembers an emitted opcode and where it starts
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope holds the instructions of the function body currently being compiled
+type CompilationScope struct {
+	instructions        code.Instructions
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	loops []*loop     // loops enclosing the code being compiled, innermost last
+	tries []*tryBlock // try expressions enclosing the code being compiled, innermost last
+
+	positions []object.SourcePosition // where the code of the instructions is, for the positions of runtime errors
+}
+
+// loop tracks where break and continue jump to in a loop being compiled
+type loop struct {
+	start      int   // first instruction of an iteration, continue jumps here
+	breakJumps []int // positions of the jumps emitted for break, patched once the loop's end is known
+	tries      int   // number of try expressions already open when the loop started
+}
+
+// tryBlock tracks what return, break and continue have to undo to leave a try expression being compiled
+type tryBlock struct {
+	handlers int                 // handlers the VM has installed for the code being compiled
+	finally  *ast.BlockStatement // runs on the way out, nil if there is none
+}
+
+// Compiler lowers an AST into bytecode for the VM
+type Compiler struct {
+	constants []object.Object
+
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	pos token.Position // position of the innermost node being compiled, instructions emitted are attributed to it
+
+	err error // set once an operand didn't fit in its instruction, compiling stops with it
+}
+
+// Bytecode is the compiler's output: the main instructions and the constant pool they refer to
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	GlobalNames  []string // the name each global slot is bound to, for the errors of reading it unset
+	Positions    []object.SourcePosition
+}
+
+// New returns a Compiler with an empty global scope
+func New() *Compiler {
+	mainScope := CompilationScope{
+		instructions:        code.Instructions{},
+		lastInstruction:     EmittedInstruction{},
+		previousInstruction: EmittedInstruction{},
+	}
+
+	return &Compiler{
+		constants:   []object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []CompilationScope{mainScope},
+		scopeIndex:  0,
+	}
+}
+
+// NewWithState returns a Compiler that continues from an existing symbol table and constant pool.
+// The REPL uses this to keep bindings between lines.
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
+// Compile lowers the given AST Node into the current compilation scope
+func (c *Compiler) Compile(node ast.Node) error {
+	defer c.enterNode(node)()
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.LetStatement:
+		// The name is only bound once the value is compiled, so the value can't refer to the new
+		// binding, only to one it shadows. Functions refer to themselves through DefineFunctionName.
+		var err error
+		if fn, ok := node.Value.(*ast.FunctionLiteral); ok {
+			err = c.compileFunction(fn, node.Name.Value, node.Doc())
+		} else {
+			err = c.Compile(node.Value)
+		}
+		if err != nil {
+			return err
+		}
+
+		c.storeSymbol(c.symbolTable.Define(node.Name.Value))
+
+	case *ast.WhileStatement:
+		start := len(c.currentInstructions())
+
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		// Emit with a bogus jump target, patched once the loop is compiled
+		exitJumpPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		end, err := c.compileLoopBody(node.Body, start)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(exitJumpPos, end)
+
+		// A loop's value is null, like in the evaluator
+		c.emit(code.OpNull)
+		c.emit(code.OpPop)
+
+	case *ast.ForStatement:
+		if err := c.Compile(node.Iterable); err != nil {
+			return err
+		}
+		c.emit(code.OpIterator)
+
+		// The iterator is kept in a variable scripts can't name, with a slot of its own in nested loops
+		iterator, restoreIterator := c.symbolTable.DefineScoped("$iterator")
+		defer restoreIterator()
+		c.storeSymbol(iterator)
+
+		start := len(c.currentInstructions())
+		c.loadSymbol(iterator)
+		nextPos := c.emit(code.OpIterNext, 9999)
+
+		c.storeSymbol(c.symbolTable.Define(node.Variable.Value))
+
+		end, err := c.compileLoopBody(node.Body, start)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(nextPos, end)
+
+		c.emit(code.OpNull)
+		c.emit(code.OpPop)
+
+	case *ast.BreakStatement:
+		loop, err := c.innermostLoop()
+		if err != nil {
+			return err
+		}
+		if err := c.leaveTries(loop.tries); err != nil {
+			return err
+		}
+		loop.breakJumps = append(loop.breakJumps, c.emit(code.OpJump, 9999))
+
+	case *ast.ContinueStatement:
+		loop, err := c.innermostLoop()
+		if err != nil {
+			return err
+		}
+		if err := c.leaveTries(loop.tries); err != nil {
+			return err
+		}
+		c.emit(code.OpJump, loop.start)
+
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if err := c.leaveTries(0); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+
+	case *ast.TryExpression:
+		return c.compileTry(node)
+
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			if index, found := evaluator.BuiltinIndex(node.Value); found {
+				symbol = c.symbolTable.global().DefineBuiltin(index, node.Value)
+			} else {
+				// Names are looked up when the code runs in the evaluator, so the name may still be bound
+				// by a later top-level let, which reuses this slot. Reading it unset is a runtime NameError.
+				symbol = c.symbolTable.global().Define(node.Value)
+			}
+		}
+		c.loadSymbol(symbol)
+
+	case *ast.IntegerLiteral:
+		integer := &object.Integer{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(str))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.NullLiteral:
+		c.emit(code.OpNull)
+
+	case *ast.PrefixExpression:
+		op, ok := prefixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogical(node)
+		}
+		if node.Operator == "??" {
+			return c.compileNullish(node)
+		}
+
+		op, ok := infixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.IfExpression:
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		// Emit with a bogus offset that is patched once the consequence is compiled
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		if err := c.compileBranch(node.Consequence); err != nil {
+			return err
+		}
+
+		jumpPos := c.emit(code.OpJump, 9999)
+
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+		if node.Alternative == nil {
+			c.emit(code.OpNull)
+		} else if err := c.compileBranch(node.Alternative); err != nil {
+			return err
+		}
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	case *ast.TemplateLiteral:
+		numParts := 0
+		for _, part := range node.Parts {
+			// Empty text around the interpolations adds nothing to the result
+			if str, ok := part.(*ast.StringLiteral); ok && str.Value == "" {
+				continue
+			}
+			if err := c.Compile(part); err != nil {
+				return err
+			}
+			numParts++
+		}
+		c.emit(code.OpTemplate, numParts)
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		skip := c.emitOptional(node.Optional)
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+		c.patchOptional(skip)
+
+	case *ast.SliceExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		skip := c.emitOptional(node.Optional)
+		for _, bound := range []ast.Expression{node.Start, node.Stop, node.Step} {
+			if bound == nil {
+				c.emit(code.OpNull)
+				continue
+			}
+			if err := c.Compile(bound); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpSlice)
+		c.patchOptional(skip)
+
+	case *ast.AssignExpression:
+		return c.compileAssign(node)
+
+	case *ast.FunctionLiteral:
+		return c.compileFunction(node, "", "")
+
+	case *ast.MacroLiteral:
+		return fmt.Errorf("macros can only be defined with a let statement at the top level")
+
+	case *ast.CallExpression:
+		// quoted code only exists while macros are expanded, before compiling
+		if ident, ok := node.Function.(*ast.Identifier); ok && (ident.Value == "quote" || ident.Value == "unquote") {
+			return fmt.Errorf("`%s` can only be used in macros", ident.Value)
+		}
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		skip := c.emitOptional(node.Optional)
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+		c.patchOptional(skip)
+
+	default:
+		return fmt.Errorf("cannot compile %T", node)
+	}
+
+	return c.err
+}
+
+// Bytecode returns the compiled main instructions and constant pool
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		GlobalNames:  c.symbolTable.global().names,
+		Positions:    c.scopes[c.scopeIndex].positions,
+	}
+}
+
+// SymbolTable returns the compiler's global symbol table
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+// compileBranch compiles an if/else block so that it leaves its value on the stack
+func (c *Compiler) compileBranch(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+// compileFunction compiles a function literal into a Closure, name and doc come from the binding it is
+// let-bound to if any
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral, name, doc string) error {
+	c.enterScope()
+
+	if name != "" {
+		c.symbolTable.DefineFunctionName(name)
+	}
+
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value)
+	}
+	if node.Rest != nil {
+		c.symbolTable.Define(node.Rest.Value)
+	}
+
+	if err := c.compileDefaults(node); err != nil {
+		return err
+	}
+
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	localNames := c.symbolTable.names
+	positions := c.scopes[c.scopeIndex].positions
+	instructions := c.leaveScope()
+
+	freeNames := make([]string, len(freeSymbols))
+	for i, s := range freeSymbols {
+		freeNames[i] = s.Name
+	}
+
+	for _, s := range freeSymbols {
+		c.captureSymbol(s)
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Name:          name,
+		Doc:           doc,
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		NumDefaults:   len(node.Defaults),
+		Variadic:      node.Rest != nil,
+		LocalNames:    localNames,
+		FreeNames:     freeNames,
+		Positions:     positions,
+	}
+
+	c.emit(code.OpClosure, c.addConstant(compiledFn), len(freeSymbols))
+
+	return nil
+}
+
+// compileDefaults emits the function prologue that stores the default value of each
+// optional parameter the caller left out
+func (c *Compiler) compileDefaults(node *ast.FunctionLiteral) error {
+	for i, p := range node.Parameters {
+		def, ok := node.Defaults[p.Value]
+		if !ok {
+			continue
+		}
+
+		// Emit with a bogus jump target, patched once the default is compiled
+		jumpPos := c.emit(code.OpJumpIfArgument, i, 9999)
+
+		if err := c.Compile(def); err != nil {
+			return err
+		}
+
+		symbol, _ := c.symbolTable.Resolve(p.Value)
+		c.emit(code.OpSetLocal, symbol.Index)
+
+		c.replaceInstruction(jumpPos, c.makeInstruction(code.OpJumpIfArgument, i, len(c.currentInstructions())))
+	}
+
+	return nil
+}
+
+// compileLoopBody compiles the body of a loop whose iterations begin at start, followed by the jump back
+// to start. It returns the position after the loop, where break statements in the body now jump to.
+func (c *Compiler) compileLoopBody(body *ast.BlockStatement, start int) (int, error) {
+	l := &loop{start: start, tries: len(c.scopes[c.scopeIndex].tries)}
+
+	c.scopes[c.scopeIndex].loops = append(c.scopes[c.scopeIndex].loops, l)
+	err := c.Compile(body)
+	loops := c.scopes[c.scopeIndex].loops
+	c.scopes[c.scopeIndex].loops = loops[:len(loops)-1]
+
+	if err != nil {
+		return 0, err
+	}
+
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	for _, pos := range l.breakJumps {
+		c.changeOperand(pos, end)
+	}
+
+	return end, nil
+}
+
+// compileTry compiles a try expression so that it leaves the value of its try or catch block on the stack.
+// The VM jumps to the catch and finally blocks through handlers when an error is raised, the finally block
+// is also compiled after the try and catch blocks and before any return, break or continue leaving them.
+func (c *Compiler) compileTry(node *ast.TryExpression) error {
+	t := &tryBlock{finally: node.Finally}
+	tries := c.scopes[c.scopeIndex].tries
+	c.scopes[c.scopeIndex].tries = append(tries, t)
+
+	// Emit with bogus targets, patched once the catch and finally blocks are compiled
+	finallyPos, catchPos := 0, 0
+	if node.Finally != nil {
+		finallyPos = c.emit(code.OpSetupFinally, 9999)
+		t.handlers++
+	}
+	if node.Catch != nil {
+		catchPos = c.emit(code.OpSetupCatch, 9999)
+		t.handlers++
+	}
+
+	err := c.compileBranch(node.Block)
+	if err == nil && node.Catch != nil {
+		err = c.compileCatch(node, t, catchPos)
+	}
+	c.scopes[c.scopeIndex].tries = tries
+	if err != nil || node.Finally == nil {
+		return err
+	}
+
+	c.emit(code.OpPopHandler)
+	if err := c.Compile(node.Finally); err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	// An error raised in the try or catch block is raised again after the finally block
+	c.changeOperand(finallyPos, len(c.currentInstructions()))
+	if err := c.Compile(node.Finally); err != nil {
+		return err
+	}
+	c.emit(code.OpThrow)
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// compileCatch compiles the catch block of node, which the handler emitted at catchPos jumps to with the
+// caught error's hash pushed. The try block jumps over it.
+func (c *Compiler) compileCatch(node *ast.TryExpression, t *tryBlock, catchPos int) error {
+	c.emit(code.OpPopHandler)
+	t.handlers--
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(catchPos, len(c.currentInstructions()))
+
+	// Without a parameter the hash is kept in a variable scripts can't name
+	name := "$error"
+	if node.CatchParam != nil {
+		name = node.CatchParam.Value
+	}
+	param, restoreParam := c.symbolTable.DefineScoped(name)
+	c.storeSymbol(param)
+	err := c.compileBranch(node.Catch)
+	restoreParam()
+	if err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// leaveTries emits the code leaving the try expressions enclosing the current position, all but the
+// outermost depth ones: their handlers are removed and their finally blocks run, innermost first
+func (c *Compiler) leaveTries(depth int) error {
+	tries := c.scopes[c.scopeIndex].tries
+	defer func() { c.scopes[c.scopeIndex].tries = tries }()
+
+	for i := len(tries) - 1; i >= depth; i-- {
+		for j := 0; j < tries[i].handlers; j++ {
+			c.emit(code.OpPopHandler)
+		}
+
+		if tries[i].finally != nil {
+			// a return, break or continue in the finally block only leaves the try expressions around it
+			c.scopes[c.scopeIndex].tries = tries[:i]
+			if err := c.Compile(tries[i].finally); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compileLogical compiles && and || so the right operand only runs when the left one doesn't decide
+// the result. Like the evaluator, the result is the truthiness of the deciding operand.
+func (c *Compiler) compileLogical(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	// Emit with a bogus offset that is patched once the truthy branch is compiled
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	// A truthy left operand leaves && to the right operand and decides ||
+	if node.Operator == "&&" {
+		if err := c.compileTruthiness(node.Right); err != nil {
+			return err
+		}
+	} else {
+		c.emit(code.OpTrue)
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	// A falsy left operand decides && and leaves || to the right operand
+	if node.Operator == "&&" {
+		c.emit(code.OpFalse)
+	} else if err := c.compileTruthiness(node.Right); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileNullish compiles ?? so the right operand only runs when the left one is null
+func (c *Compiler) compileNullish(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	// Emit with bogus offsets that are patched once the right operand is compiled
+	jumpNullPos := c.emit(code.OpJumpNull, 9999)
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNullPos, len(c.currentInstructions()))
+	c.emit(code.OpPop)
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// emitOptional emits the jump that skips an optional index or call when the value it applies to is null,
+// returning its position to patch, or -1 when the operation isn't optional
+func (c *Compiler) emitOptional(optional bool) int {
+	if !optional {
+		return -1
+	}
+	return c.emit(code.OpJumpNull, 9999)
+}
+
+// patchOptional points the jump emitted by emitOptional past the operation compiled since
+func (c *Compiler) patchOptional(pos int) {
+	if pos >= 0 {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+}
+
+// compileTruthiness compiles exp and converts its value to true or false
+func (c *Compiler) compileTruthiness(exp ast.Expression) error {
+	if err := c.Compile(exp); err != nil {
+		return err
+	}
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	return nil
+}
+
+// compileAssign compiles an assignment, leaving the assigned value on the stack.
+// Compound operators load the current value and apply their infix opcode before storing.
+func (c *Compiler) compileAssign(node *ast.AssignExpression) error {
+	op, compound := infixOpcodes[strings.TrimSuffix(node.Operator, "=")]
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if !ok {
+			return fmt.Errorf("cannot assign to undeclared identifier: %s", target.Value)
+		}
+		// A function refers to itself by name through its closure, which isn't a variable to assign to
+		if c.symbolTable.origin(symbol).Scope == FunctionScope {
+			return fmt.Errorf("cannot assign to %s from this scope", target.Value)
+		}
+
+		if compound {
+			c.loadSymbol(symbol)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+
+		c.storeSymbol(symbol)
+		c.loadSymbol(symbol)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(code.OpDup2)
+			c.emit(code.OpIndex)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+
+		c.emit(code.OpSetIndex)
+
+	default:
+		return fmt.Errorf("cannot assign to %s", node.Target.String())
+	}
+
+	return nil
+}
+
+// innermostLoop returns the loop that break and continue apply to
+func (c *Compiler) innermostLoop() (*loop, error) {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil, fmt.Errorf("break or continue outside of a loop")
+	}
+	return loops[len(loops)-1], nil
+}
+
+// storeSymbol emits the instruction that pops the top of the stack into s
+func (c *Compiler) storeSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpSetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+}
+
+// loadSymbol emits the instruction that pushes the value bound to s
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+// captureSymbol emits the instruction that pushes what a closure captures s as. Variables are shared
+// through cells, so assignments made by the closure and by the scope defining them are seen by both.
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+// addConstant appends obj to the constant pool and returns its index
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// emit appends an instruction to the current scope and returns its position
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := c.makeInstruction(op, operands...)
+	pos := c.addInstruction(ins)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+// makeInstruction encodes an instruction like code.Make, which would silently truncate an operand
+// too large for its width, so that is recorded as the compiler's error instead
+func (c *Compiler) makeInstruction(op code.Opcode, operands ...int) []byte {
+	def, _ := code.Lookup(byte(op))
+	for i, o := range operands {
+		max := 1<<(8*def.OperandWidths[i]) - 1
+		if o > max && c.err == nil {
+			c.err = fmt.Errorf("program too large: %s %d is over the limit of %d", operandNames[op][i], o, max)
+		}
+	}
+
+	return code.Make(op, operands...)
+}
+
+// enterNode attributes the instructions emitted from now on to node, until the returned function is called
+func (c *Compiler) enterNode(node ast.Node) func() {
+	outer := c.pos
+	if pos := node.Pos(); pos.IsValid() {
+		c.pos = pos
+	}
+	return func() { c.pos = outer }
+}
+
+// addInstruction appends raw instruction bytes to the current scope
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	updatedInstructions := append(c.currentInstructions(), ins...)
+
+	c.scopes[c.scopeIndex].instructions = updatedInstructions
+
+	positions := c.scopes[c.scopeIndex].positions
+	if len(positions) == 0 || positions[len(positions)-1].Pos != c.pos {
+		c.scopes[c.scopeIndex].positions = append(positions, object.SourcePosition{Offset: posNewInstruction, Pos: c.pos})
+	}
+
+	return posNewInstruction
+}
+
+// setLastInstruction tracks the two most recently emitted instructions
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+// lastInstructionIs returns true if the last emitted instruction has the given opcode
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+// removeLastPop drops a trailing OpPop so the value stays on the stack
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+
+	old := c.currentInstructions()
+	new := old[:last.Position]
+
+	c.scopes[c.scopeIndex].instructions = new
+	c.scopes[c.scopeIndex].lastInstruction = previous
+
+	positions := c.scopes[c.scopeIndex].positions
+	for len(positions) > 0 && positions[len(positions)-1].Offset >= len(new) {
+		positions = positions[:len(positions)-1]
+	}
+	c.scopes[c.scopeIndex].positions = positions
+}
+
+// replaceInstruction overwrites the instruction at pos with newInstruction of the same width
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+// changeOperand re-encodes the instruction at opPos with a new operand
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	newInstruction := c.makeInstruction(op, operand)
+
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+// replaceLastPopWithReturn turns the implicit value of a function body into its return value
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+// currentInstructions returns the instructions of the scope being compiled
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+// enterScope starts compiling a new function body
+func (c *Compiler) enterScope() {
+	scope := CompilationScope{
+		instructions:        code.Instructions{},
+		lastInstruction:     EmittedInstruction{},
+		previousInstruction: EmittedInstruction{},
+	}
+	c.scopes = append(c.scopes, scope)
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+// leaveScope finishes the current function body and returns its instructions
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.currentInstructions()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return instructions
+}
@@ -0,0 +1,146 @@
+package compiler
+
+type SymbolScope string
+
+// Scopes a Symbol can be resolved in
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	BuiltinScope  SymbolScope = "BUILTIN"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+)
+
+// Symbol is a name bound in a scope along with its index in that scope's storage
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable maps names to Symbols for the current scope and links to the enclosing scope
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store          map[string]Symbol
+	numDefinitions int
+	names          []string // the name each slot was defined for
+
+	FreeSymbols []Symbol
+}
+
+// NewSymbolTable creates a new global SymbolTable
+func NewSymbolTable() *SymbolTable {
+	s := make(map[string]Symbol)
+	free := []Symbol{}
+	return &SymbolTable{store: s, FreeSymbols: free}
+}
+
+// NewEnclosedSymbolTable creates a new local SymbolTable that extends the outer one
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define binds name in the current scope and returns its Symbol.
+// Redefining a name already bound in this scope reuses its slot, like let overwrites a binding in the evaluator.
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) {
+		return symbol
+	}
+
+	return s.define(name)
+}
+
+// define binds name to a new slot in the current scope
+func (s *SymbolTable) define(name string) Symbol {
+	symbol := Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+	} else {
+		symbol.Scope = LocalScope
+	}
+
+	s.store[name] = symbol
+	s.names = append(s.names, name)
+	s.numDefinitions++
+	return symbol
+}
+
+// DefineScoped binds name to a new slot and returns a function that restores whatever name was bound to
+// in this scope before
+func (s *SymbolTable) DefineScoped(name string) (Symbol, func()) {
+	prev, hadPrev := s.store[name]
+	symbol := s.define(name)
+
+	restore := func() {
+		if hadPrev {
+			s.store[name] = prev
+		} else {
+			delete(s.store, name)
+		}
+	}
+
+	return symbol, restore
+}
+
+// DefineBuiltin binds name to the builtin at the given index of evaluator.Builtins
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// DefineFunctionName binds the name a function literal is let-bound to, so it can call itself
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FunctionScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+// defineFree records original as a free variable of the current scope
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1}
+	symbol.Scope = FreeScope
+
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+// Resolve returns the Symbol bound to name in the current or outer scopes
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	obj, ok := s.store[name]
+	if !ok && s.Outer != nil {
+		obj, ok = s.Outer.Resolve(name)
+		if !ok {
+			return obj, ok
+		}
+
+		if obj.Scope == GlobalScope || obj.Scope == BuiltinScope {
+			return obj, ok
+		}
+
+		free := s.defineFree(obj)
+		return free, true
+	}
+	return obj, ok
+}
+
+// origin returns the symbol sym refers to in the scope that binds it, following free symbols outwards
+func (s *SymbolTable) origin(sym Symbol) Symbol {
+	for table := s; sym.Scope == FreeScope; table = table.Outer {
+		sym = table.FreeSymbols[sym.Index]
+	}
+	return sym
+}
+
+// global returns the outermost SymbolTable
+func (s *SymbolTable) global() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+	return s
+}
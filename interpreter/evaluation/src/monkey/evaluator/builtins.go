@@ -2,24 +2,34 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
 )
 
+// maxRangeLength bounds the arrays range builds, which are allocated before any step is counted
+const maxRangeLength = 10_000_000
+
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			switch arg := args[0].(type) {
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
 			default:
-				return newError("argument to `len` not supported, got %s", args[0].Type())
+				return newError(object.TYPE_ERROR, "argument to `len` not supported, got %s", args[0].Type())
 			}
 		},
 	},
@@ -34,11 +44,11 @@ var builtins = map[string]*object.Builtin{
 	"first": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must be ARRAY, got %s", args[0].Type())
+				return newError(object.TYPE_ERROR, "argument to `first` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -53,11 +63,11 @@ var builtins = map[string]*object.Builtin{
 	"last": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `last` must be ARRAY, got %s", args[0].Type())
+				return newError(object.TYPE_ERROR, "argument to `last` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -73,11 +83,11 @@ var builtins = map[string]*object.Builtin{
 	"rest": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `rest` must be ARRAY, got %s", args[0].Type())
+				return newError(object.TYPE_ERROR, "argument to `rest` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -95,11 +105,11 @@ var builtins = map[string]*object.Builtin{
 	"push": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
+				return newError(object.TYPE_ERROR, "argument to `push` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -112,4 +122,586 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+
+	"keys": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashElements("keys", args, func(pair object.HashPair) object.Object { return pair.Key })
+		},
+	},
+
+	"values": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashElements("values", args, func(pair object.HashPair) object.Object { return pair.Value })
+		},
+	},
+
+	// entries returns the pairs of a hash as [key, value] arrays
+	"entries": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return hashElements("entries", args, func(pair object.HashPair) object.Object {
+				return &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+			})
+		},
+	},
+
+	"has": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			if args[0].Type() != object.HASH_OBJ {
+				return newError(object.TYPE_ERROR, "argument to `has` must be HASH, got %s", args[0].Type())
+			}
+
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError(object.TYPE_ERROR, "unusable as hash key: %s", args[1].Type())
+			}
+
+			_, ok = args[0].(*object.Hash).Pairs[key.HashKey()]
+			return NativeBoolToBooleanObject(ok)
+		},
+	},
+
+	// delete returns a copy of the hash without the given key, the hash itself is left unchanged
+	"delete": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			if args[0].Type() != object.HASH_OBJ {
+				return newError(object.TYPE_ERROR, "argument to `delete` must be HASH, got %s", args[0].Type())
+			}
+
+			key, ok := args[1].(object.Hashable)
+			if !ok {
+				return newError(object.TYPE_ERROR, "unusable as hash key: %s", args[1].Type())
+			}
+
+			original := args[0].(*object.Hash)
+			deleted := key.HashKey()
+
+			hash := object.NewHash()
+			for _, k := range original.Order {
+				if k != deleted {
+					hash.Set(k, original.Pairs[k])
+				}
+			}
+			return hash
+		},
+	},
+
+	// merge returns a new hash with the pairs of both hashes, the second one wins when both have a key
+	"merge": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			hash := object.NewHash()
+			for _, arg := range args {
+				h, ok := arg.(*object.Hash)
+				if !ok {
+					return newError(object.TYPE_ERROR, "argument to `merge` must be HASH, got %s", arg.Type())
+				}
+				for _, k := range h.Order {
+					hash.Set(k, h.Pairs[k])
+				}
+			}
+			return hash
+		},
+	},
+
+	"int": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				return floatToInteger(math.Trunc(arg.Value))
+			case *object.String:
+				value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+				if err != nil {
+					return newError(object.TYPE_ERROR, "cannot convert %q to INTEGER", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError(object.TYPE_ERROR, "argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
+	"float": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return &object.Float{Value: float64(arg.Value)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError(object.TYPE_ERROR, "cannot convert %q to FLOAT", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError(object.TYPE_ERROR, "argument to `float` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// round rounds halfway cases away from zero. Given a number of digits it returns a FLOAT
+	// rounded to that many decimal places, otherwise an INTEGER.
+	"round": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+
+			if !isNumber(args[0]) {
+				return newError(object.TYPE_ERROR, "argument to `round` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+
+			if len(args) == 1 {
+				if args[0].Type() == object.INTEGER_OBJ {
+					return args[0]
+				}
+				return floatToInteger(math.Round(toFloat(args[0])))
+			}
+
+			digits, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError(object.TYPE_ERROR, "digits given to `round` must be INTEGER, got %s", args[1].Type())
+			}
+
+			scale := math.Pow(10, float64(digits.Value))
+			return &object.Float{Value: math.Round(toFloat(args[0])*scale) / scale}
+		},
+	},
+
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+
+	"map": &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			arr, fn, err := arrayAndFunction("map", args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			mapped := make([]object.Object, len(arr.Elements))
+			for i, elem := range arr.Elements {
+				result := call(fn, elem)
+				if isError(result) {
+					return result
+				}
+				mapped[i] = result
+			}
+			return &object.Array{Elements: mapped}
+		},
+	},
+
+	"filter": &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			arr, fn, err := arrayAndFunction("filter", args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			filtered := []object.Object{}
+			for _, elem := range arr.Elements {
+				result := call(fn, elem)
+				if isError(result) {
+					return result
+				}
+				if IsTruthy(result) {
+					filtered = append(filtered, elem)
+				}
+			}
+			return &object.Array{Elements: filtered}
+		},
+	},
+
+	// reduce folds the array into a single value by calling fn(accumulator, element) on each element.
+	// Without an initial value the first element starts the accumulator.
+	"reduce": &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2..3", len(args))
+			}
+
+			arr, fn, err := arrayAndFunction("reduce", args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			elements := arr.Elements
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else {
+				if len(elements) == 0 {
+					return newError(object.ARGUMENT_ERROR, "`reduce` of an empty array needs an initial value")
+				}
+				acc, elements = elements[0], elements[1:]
+			}
+
+			for _, elem := range elements {
+				acc = call(fn, acc, elem)
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	},
+
+	// sort returns a sorted copy of the array, ordered by < or by a less(a, b) function.
+	// Equal elements keep their order.
+	"sort": &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError(object.TYPE_ERROR, "argument to `sort` must be ARRAY, got %s", args[0].Type())
+			}
+
+			less := func(a, b object.Object) object.Object { return EvalInfix("<", a, b, nil) }
+			if len(args) == 2 {
+				if !isCallable(args[1]) {
+					return newError(object.TYPE_ERROR, "argument to `sort` must be FUNCTION, got %s", args[1].Type())
+				}
+				less = func(a, b object.Object) object.Object { return call(args[1], a, b) }
+			}
+
+			sorted := make([]object.Object, len(arr.Elements))
+			copy(sorted, arr.Elements)
+
+			var failed object.Object
+			sort.SliceStable(sorted, func(i, j int) bool {
+				if failed != nil {
+					return false
+				}
+				result := less(sorted[i], sorted[j])
+				if isError(result) {
+					failed = result
+					return false
+				}
+				return IsTruthy(result)
+			})
+			if failed != nil {
+				return failed
+			}
+
+			return &object.Array{Elements: sorted}
+		},
+	},
+
+	// find returns the first element fn is truthy for, or null
+	"find": &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			arr, fn, err := arrayAndFunction("find", args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			for _, elem := range arr.Elements {
+				result := call(fn, elem)
+				if isError(result) {
+					return result
+				}
+				if IsTruthy(result) {
+					return elem
+				}
+			}
+			return NULL
+		},
+	},
+
+	"any": quantifierBuiltin("any", true),
+	"all": quantifierBuiltin("all", false),
+
+	// zip pairs up the elements of two arrays, stopping at the end of the shorter one
+	"zip": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			for _, arg := range args {
+				if arg.Type() != object.ARRAY_OBJ {
+					return newError(object.TYPE_ERROR, "argument to `zip` must be ARRAY, got %s", arg.Type())
+				}
+			}
+
+			left, right := args[0].(*object.Array).Elements, args[1].(*object.Array).Elements
+			length := len(left)
+			if len(right) < length {
+				length = len(right)
+			}
+
+			pairs := make([]object.Object, length)
+			for i := 0; i < length; i++ {
+				pairs[i] = &object.Array{Elements: []object.Object{left[i], right[i]}}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+
+	// range returns the integers from start up to, but not including, stop: range(stop), range(start, stop)
+	// or range(start, stop, step). A negative step counts down.
+	"range": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..3", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError(object.TYPE_ERROR, "argument to `range` must be INTEGER, got %s", arg.Type())
+				}
+				bounds[i] = integer.Value
+			}
+
+			start, stop, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, stop = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError(object.ARGUMENT_ERROR, "`range` step cannot be zero")
+			}
+
+			n := rangeLength(start, stop, step)
+			if n > maxRangeLength {
+				return newError(object.ARGUMENT_ERROR, "`range` of %d elements exceeds the limit of %d", n, maxRangeLength)
+			}
+
+			elements := make([]object.Object, n)
+			for i := range elements {
+				elements[i] = &object.Integer{Value: start + int64(i)*step}
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	"reverse": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				length := len(arg.Elements)
+				reversed := make([]object.Object, length)
+				for i, elem := range arg.Elements {
+					reversed[length-1-i] = elem
+				}
+				return &object.Array{Elements: reversed}
+			case *object.String:
+				runes := []rune(arg.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			default:
+				return newError(object.TYPE_ERROR, "argument to `reverse` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	// help returns the doc comment of a let-bound function, or null if it has none
+	"help": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			doc := ""
+			switch arg := args[0].(type) {
+			case *object.Function:
+				doc = arg.Doc
+			case *object.Closure:
+				doc = arg.Fn.Doc
+			case *object.Builtin:
+			default:
+				return newError(object.TYPE_ERROR, "argument to `help` must be a function, got %s", args[0].Type())
+			}
+
+			if doc == "" {
+				return NULL
+			}
+			return &object.String{Value: doc}
+		},
+	},
+}
+
+// roundingBuiltin returns a builtin that rounds a number to an INTEGER with the given function
+func roundingBuiltin(name string, round func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				return floatToInteger(round(arg.Value))
+			default:
+				return newError(object.TYPE_ERROR, "argument to `%s` must be INTEGER or FLOAT, got %s", name, args[0].Type())
+			}
+		},
+	}
+}
+
+// hashElements returns an array built from the pairs of the single hash argument, in insertion order
+func hashElements(name string, args []object.Object, element func(object.HashPair) object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError(object.TYPE_ERROR, "argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+
+	elements := make([]object.Object, 0, len(hash.Order))
+	for _, pair := range hash.Ordered() {
+		elements = append(elements, element(pair))
+	}
+	return &object.Array{Elements: elements}
+}
+
+// quantifierBuiltin returns a builtin reporting whether any or all elements of an array pass a test function,
+// or are truthy without one. It stops at the first element whose truthiness is decisive.
+func quantifierBuiltin(name string, decisive bool) *object.Builtin {
+	return &object.Builtin{
+		HigherOrder: func(call object.CallFunction, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments. got=%d, want=1..2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError(object.TYPE_ERROR, "argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+			}
+			if len(args) == 2 && !isCallable(args[1]) {
+				return newError(object.TYPE_ERROR, "argument to `%s` must be FUNCTION, got %s", name, args[1].Type())
+			}
+
+			for _, elem := range arr.Elements {
+				result := elem
+				if len(args) == 2 {
+					result = call(args[1], elem)
+					if isError(result) {
+						return result
+					}
+				}
+				if IsTruthy(result) == decisive {
+					return NativeBoolToBooleanObject(decisive)
+				}
+			}
+			return NativeBoolToBooleanObject(!decisive)
+		},
+	}
+}
+
+// arrayAndFunction checks the arguments of a builtin that calls fn on the elements of an array
+func arrayAndFunction(name string, arr, fn object.Object) (*object.Array, object.Object, *object.Error) {
+	array, ok := arr.(*object.Array)
+	if !ok {
+		return nil, nil, newError(object.TYPE_ERROR, "argument to `%s` must be ARRAY, got %s", name, arr.Type())
+	}
+	if !isCallable(fn) {
+		return nil, nil, newError(object.TYPE_ERROR, "argument to `%s` must be FUNCTION, got %s", name, fn.Type())
+	}
+	return array, fn, nil
+}
+
+// isCallable reports whether obj is a function either engine can call
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Closure, *object.Builtin:
+		return true
+	default:
+		return false
+	}
+}
+
+// floatToInteger converts a whole number to an Integer, infinities, NaN and values outside the range of INTEGER are errors
+func floatToInteger(value float64) object.Object {
+	if math.IsNaN(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+		return newError(object.ARITHMETIC_ERROR, "cannot convert %s to INTEGER", (&object.Float{Value: value}).Inspect())
+	}
+	return &object.Integer{Value: int64(value)}
+}
+
+// rangeLength returns how many values range visits going from start towards stop by step
+func rangeLength(start, stop, step int64) uint64 {
+	// the distances are computed as uint64 so they can't overflow
+	switch {
+	case step > 0 && start < stop:
+		return (uint64(stop)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > stop:
+		return (uint64(start)-uint64(stop)-1)/(uint64(-(step+1))+1) + 1
+	default:
+		return 0
+	}
+}
+
+// Builtins holds every builtin function sorted by name, compiled code refers to a builtin by its index here
+var Builtins, builtinIndexes = orderBuiltins()
+
+// orderBuiltins lays the builtins out by name and maps each name to its index
+func orderBuiltins() ([]*object.Builtin, map[string]int) {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]*object.Builtin, len(names))
+	indexes := make(map[string]int, len(names))
+	for i, name := range names {
+		ordered[i] = builtins[name]
+		indexes[name] = i
+	}
+	return ordered, indexes
+}
+
+// BuiltinIndex returns the index in Builtins of the builtin registered under name
+func BuiltinIndex(name string) (int, bool) {
+	index, ok := builtinIndexes[name]
+	return index, ok
 }
@@ -2,8 +2,11 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
 )
 
@@ -11,10 +14,34 @@ var (
 	NULL  = &object.Null{}
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
-// Eval evaluates the given AST Node
+// DefaultMaxDepth is a call depth limit deep enough for ordinary recursion that stops runaway
+// recursion well before it exhausts the Go stack
+const DefaultMaxDepth = 10000
+
+// Eval evaluates the given AST Node, counting it against the Limits set on env.
+// Errors are stamped with the position of the innermost Node that produced them.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	var result object.Object
+	if err := TakeStep(env.Limits()); err != nil {
+		result = err
+	} else {
+		result = evalNode(node, env)
+	}
+
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+
+	return result
+}
+
+// evalNode evaluates the given AST Node depending on its type
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 
 	switch node := node.(type) {
 	case *ast.Program:
@@ -26,15 +53,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.Boolean:
-		return nativeBoolToBooleanObject(node.Value)
+		return NativeBoolToBooleanObject(node.Value)
+
+	case *ast.NullLiteral:
+		return NULL
 
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return EvalPrefix(node.Operator, right, env.Limits())
 
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
@@ -42,11 +75,23 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return left
 		}
 
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, left, env)
+		}
+
+		// ?? only evaluates the right operand when the left one is null
+		if node.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
+
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return EvalInfix(node.Operator, left, right, env.Limits())
 
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
@@ -66,31 +111,77 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+
+		// Name function literals after their binding so tracebacks can refer to them, and help can
+		// show the comments above it
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			if _, isLiteral := node.Value.(*ast.FunctionLiteral); isLiteral {
+				fn.Name = node.Name.Value
+				fn.Doc = node.Doc()
+			}
+		}
+
 		env.Set(node.Name.Value, val)
 
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
+
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Env: env, Body: body}
+
+	case *ast.MacroLiteral:
+		return newError(object.MACRO_ERROR, "macros can only be defined with a let statement at the top level")
 
 	case *ast.CallExpression:
+		// quote and unquote are special forms, their arguments are code rather than values
+		if isCallTo(node, "quote") {
+			return evalQuote(node, env)
+		}
+		if isCallTo(node, "unquote") {
+			return newError(object.MACRO_ERROR, "`unquote` can only be called inside `quote`")
+		}
+
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
+		if node.Optional && function == NULL {
+			return NULL
+		}
 		args := evalExpressions(node.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		return ApplyFunction(function, args, node.Pos())
 
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.TemplateLiteral:
+		values := evalExpressions(node.Parts, env)
+		if len(values) == 1 && isError(values[0]) {
+			return values[0]
+		}
+		return Interpolate(values)
+
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -104,15 +195,51 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(left) {
 			return left
 		}
+		if node.Optional && left == NULL {
+			return NULL
+		}
 		index := Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
 
-		return evalIndexExpression(left, index)
+		return EvalIndex(left, index)
+
+	case *ast.SliceExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		if node.Optional && left == NULL {
+			return NULL
+		}
+
+		// omitted bounds are passed on as null
+		bounds := make([]object.Object, 3)
+		for i, bound := range []ast.Expression{node.Start, node.Stop, node.Step} {
+			bounds[i] = NULL
+			if bound != nil {
+				bounds[i] = Eval(bound, env)
+				if isError(bounds[i]) {
+					return bounds[i]
+				}
+			}
+		}
+
+		return EvalSlice(left, bounds[0], bounds[1], bounds[2])
 
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+
+	case *ast.ThrowStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return ThrownError(val)
+
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
 	}
 
 	return nil
@@ -138,23 +265,23 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-// nativeBoolToBooleanObject returns corresponding Boolean object
-func nativeBoolToBooleanObject(input bool) *object.Boolean {
+// NativeBoolToBooleanObject returns corresponding Boolean object
+func NativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return TRUE
 	}
 	return FALSE
 }
 
-// evalPrefixExpression evaluates and returns a PrefixExpression Node
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+// EvalPrefix applies a prefix operator to an already evaluated operand, limits decide whether integer overflow is an error
+func EvalPrefix(operator string, right object.Object, limits *object.Limits) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusOperatorExpression(right)
+		return evalMinusOperatorExpression(right, checksOverflow(limits))
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -173,78 +300,310 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 // evalMinusOperatorExpression evaluates prefix expression inolving (-)
-func evalMinusOperatorExpression(right object.Object) object.Object {
+func evalMinusOperatorExpression(right object.Object, checkOverflow bool) object.Object {
+	if f, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
 
 	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: -%s", right.Type())
 	}
 
 	value := right.(*object.Integer).Value
+	if checkOverflow && value == math.MinInt64 {
+		return newError(object.ARITHMETIC_ERROR, "integer overflow: -(%d)", value)
+	}
+
 	return &object.Integer{Value: -value}
 
 }
 
-// evalInfixExpression evaluates infix expressions
-func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+// EvalInfix applies an infix operator to already evaluated operands, limits decide whether integer overflow is an error
+func EvalInfix(operator string, left object.Object, right object.Object, limits *object.Limits) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(operator, left, right, checksOverflow(limits))
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return NativeBoolToBooleanObject(objectsEqual(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return NativeBoolToBooleanObject(!objectsEqual(left, right))
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
 // evalIntegerInfixExpression evaluates Infix Expressions involving Integer operands
-func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func evalIntegerInfixExpression(operator string, left object.Object, right object.Object, checkOverflow bool) object.Object {
 
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
+	switch operator {
+	case "+", "-", "*", "/", "%", "**":
+		return evalIntegerArithmetic(operator, leftVal, rightVal, checkOverflow)
+	case "<":
+		return NativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return NativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return NativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return NativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return NativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return NativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalIntegerArithmetic applies an arithmetic operator to Integer values.
+// Division by zero is an error, overflowing int64 is only an error when checkOverflow is set.
+func evalIntegerArithmetic(operator string, leftVal, rightVal int64, checkOverflow bool) object.Object {
+	var result int64
+	var overflow bool
+
+	switch operator {
+	case "+":
+		result = leftVal + rightVal
+		overflow = (leftVal >= 0) == (rightVal >= 0) && (result >= 0) != (leftVal >= 0)
+	case "-":
+		result = leftVal - rightVal
+		overflow = (leftVal >= 0) != (rightVal >= 0) && (result >= 0) != (leftVal >= 0)
+	case "*":
+		result = leftVal * rightVal
+		overflow = leftVal != 0 && (result/leftVal != rightVal || (leftVal == -1 && rightVal == math.MinInt64))
+	case "/":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		result = leftVal / rightVal
+		overflow = leftVal == math.MinInt64 && rightVal == -1
+	case "%":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		result = leftVal % rightVal
+	case "**":
+		// a negative exponent gives a fraction
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		result, overflow = integerPower(leftVal, rightVal)
+	}
+
+	if checkOverflow && overflow {
+		return newError(object.ARITHMETIC_ERROR, "integer overflow: %d %s %d", leftVal, operator, rightVal)
+	}
+
+	return &object.Integer{Value: result}
+}
+
+// checksOverflow reports whether limits make integer overflow an error
+func checksOverflow(limits *object.Limits) bool {
+	return limits != nil && limits.CheckOverflow
+}
+
+// isNumber returns true if obj is an Integer or a Float
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat returns the value of an Integer or Float as a float64
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// evalFloatInfixExpression evaluates Infix Expressions where at least one operand is a Float,
+// the other is converted to a Float first
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError(object.ARITHMETIC_ERROR, "division by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
+		return NativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
+		return NativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return NativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return NativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return NativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return NativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-// evalStringInfixExpression returns string concatenated string
+// integerPower raises base to a non-negative exp by repeated squaring,
+// reporting whether the true result overflows int64
+func integerPower(base, exp int64) (int64, bool) {
+	result := int64(1)
+	overflow := false
+
+	for exp > 0 {
+		if exp&1 == 1 {
+			product := result * base
+			overflow = overflow || (result != 0 && (product/result != base || (result == -1 && base == math.MinInt64)))
+			result = product
+		}
+		exp >>= 1
+		if exp > 0 {
+			square := base * base
+			overflow = overflow || (base != 0 && (square/base != base || base == math.MinInt64))
+			base = square
+		}
+	}
+
+	return result, overflow
+}
+
+// evalLogicalExpression evaluates && and ||, only evaluating the right operand when the left one
+// doesn't decide the result. The result is the truthiness of the operand that decided it.
+func evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	if IsTruthy(left) == (node.Operator == "||") {
+		return NativeBoolToBooleanObject(IsTruthy(left))
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return NativeBoolToBooleanObject(IsTruthy(right))
+}
+
+// evalStringInfixExpression concatenates or compares strings.
+// Strings are ordered lexicographically by Unicode code point.
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
-	// TODO: Add string comparisons (== and !=)
 	switch operator {
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return NativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return NativeBoolToBooleanObject(leftVal != rightVal)
+	case "<":
+		return NativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return NativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return NativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return NativeBoolToBooleanObject(leftVal >= rightVal)
 	default:
-		return newError("unkown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TYPE_ERROR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// Interpolate renders the evaluated parts of a template string into a String, using the Inspect
+// representation of each value
+func Interpolate(values []object.Object) *object.String {
+	var out strings.Builder
+	for _, value := range values {
+		out.WriteString(value.Inspect())
+	}
+	return &object.String{Value: out.String()}
+}
+
+// objectsEqual reports whether two objects are equal. Numbers and strings compare by value, arrays and
+// hashes compare their elements, and other objects such as functions are only equal to themselves.
+func objectsEqual(left, right object.Object) bool {
+	return deepEqual(left, right, map[[2]object.Object]bool{})
+}
+
+// deepEqual compares left and right, tracking the pairs of arrays and hashes already being compared
+// so that collections containing themselves don't recurse forever
+func deepEqual(left, right object.Object, comparing map[[2]object.Object]bool) bool {
+	if left == right {
+		return true
+	}
+
+	switch l := left.(type) {
+	case *object.Integer, *object.Float:
+		return isNumber(right) && EvalInfix("==", left, right, nil) == TRUE
+
+	case *object.String:
+		r, ok := right.(*object.String)
+		return ok && l.Value == r.Value
+
+	case *object.Array:
+		r, ok := right.(*object.Array)
+		if !ok || len(l.Elements) != len(r.Elements) {
+			return false
+		}
+
+		pair := [2]object.Object{left, right}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+
+		for i := range l.Elements {
+			if !deepEqual(l.Elements[i], r.Elements[i], comparing) {
+				return false
+			}
+		}
+		return true
+
+	case *object.Hash:
+		r, ok := right.(*object.Hash)
+		if !ok || len(l.Pairs) != len(r.Pairs) {
+			return false
+		}
+
+		pair := [2]object.Object{left, right}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+
+		for key, lp := range l.Pairs {
+			rp, ok := r.Pairs[key]
+			if !ok || !deepEqual(lp.Value, rp.Value, comparing) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
 // evalIfExpression evaluates an if conditional expression
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
@@ -252,7 +611,7 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 		return condition
 	}
 
-	if isTruthy(condition) {
+	if IsTruthy(condition) {
 		return Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
@@ -261,8 +620,8 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
-// isTruthy returns true if object is not NULL or false
-func isTruthy(obj object.Object) bool {
+// IsTruthy returns true if object is not NULL or false
+func IsTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
 		return false
@@ -282,29 +641,62 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	for _, statement := range block.Statements {
 		result = Eval(statement, env)
 
-		// If result is return statement or error
+		// If result is return statement, error or loop control
 		// do not continue
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
+	}
 
-		switch result := result.(type) {
-		case *object.ReturnValue:
-			return result.Value
-		case *object.Error:
-			return result
+	return result
+}
+
+// newError returns an Error object of the given kind
+func newError(kind string, format string, a ...interface{}) *object.Error {
+	return &object.Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
+}
+
+// newFatalError returns an Error that try/catch can't handle
+func newFatalError(kind string, format string, a ...interface{}) *object.Error {
+	err := newError(kind, format, a...)
+	err.Fatal = true
+	return err
+}
+
+// TakeStep counts an evaluation step against limits, returning an error once they are exceeded
+func TakeStep(limits *object.Limits) *object.Error {
+	if limits == nil {
+		return nil
+	}
+
+	limits.Steps++
+	if limits.MaxSteps > 0 && limits.Steps > limits.MaxSteps {
+		return newFatalError(object.STEP_LIMIT_ERROR, "step limit exceeded: %d", limits.MaxSteps)
+	}
+
+	if limits.Context != nil {
+		select {
+		case <-limits.Context.Done():
+			return newFatalError(object.CANCELED_ERROR, "evaluation canceled: %s", limits.Context.Err())
+		default:
 		}
 	}
 
-	return result
+	return nil
+}
+
+// CallDepthError returns the error for making more than maxDepth nested calls
+func CallDepthError(maxDepth int) *object.Error {
+	return newFatalError(object.CALL_DEPTH_ERROR, "maximum call depth exceeded: %d", maxDepth)
 }
 
-// newError returns an Error object
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+// isFatal reports whether obj is an error that aborts evaluation
+func isFatal(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && err.Fatal
 }
 
 // isError returns true if error object
@@ -325,7 +717,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	return newError(object.NAME_ERROR, "identifier not found: "+node.Value)
 }
 
 // evalExpressions evaluates a slice of Expressions nodes into a slice of Objects
@@ -343,35 +735,103 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-// applyFunction executes the function with the given arguments
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// ApplyFunction executes the function with the given arguments, callSite is where it was called from
+func ApplyFunction(fn object.Object, args []object.Object, callSite token.Position) object.Object {
 	switch fn := fn.(type) {
 
 	case *object.Function:
+		if limits := fn.Env.Limits(); limits != nil {
+			if limits.MaxDepth > 0 && limits.Depth >= limits.MaxDepth {
+				return CallDepthError(limits.MaxDepth)
+			}
+			limits.Depth++
+			defer func() { limits.Depth-- }()
+		}
+
 		// execute fn body using the extended env
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
+
+		// record this call on errors unwinding out of the function
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Trace = append(err.Trace, object.TraceFrame{Function: functionName(fn), CallSite: callSite})
+		}
+
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
 		// execute the builtin function using the args
+		if fn.HigherOrder != nil {
+			return fn.HigherOrder(func(f object.Object, args ...object.Object) object.Object {
+				return ApplyFunction(f, args, callSite)
+			}, args...)
+		}
 		return fn.Fn(args...)
 
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newError(object.TYPE_ERROR, "not a function: %s", fn.Type())
+	}
+}
+
+// functionName returns the name fn is shown as in tracebacks
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "<anonymous>"
 	}
+	return fn.Name
 }
 
-// extendFunctionEnv returns an env that's extended with the function arguments
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// extendFunctionEnv returns an env that's extended with the function arguments. Parameters left
+// without an argument take their default value, evaluated in the new env so it can refer to earlier
+// parameters, and surplus arguments are collected into the rest parameter.
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	required := len(fn.Parameters) - len(fn.Defaults)
+	if len(args) < required || (fn.Rest == nil && len(args) > len(fn.Parameters)) {
+		return nil, ArgumentCountError(functionName(fn), required, len(fn.Parameters), fn.Rest != nil, len(args))
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	// bind the arg values to the fn params
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if paramIdx < len(args) {
+			env.Set(param.Value, args[paramIdx])
+			continue
+		}
+
+		value := Eval(fn.Defaults[param.Value], env)
+		if err, ok := value.(*object.Error); ok {
+			return nil, err
+		}
+		env.Set(param.Value, value)
 	}
 
-	return env
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
+	}
+
+	return env, nil
+}
+
+// ArgumentCountError reports a call to function name with got arguments when it takes between required
+// and total of them, or at least required if it is variadic
+func ArgumentCountError(name string, required, total int, variadic bool, got int) *object.Error {
+	want := fmt.Sprintf("%d", total)
+	switch {
+	case variadic:
+		want = fmt.Sprintf("%d+", required)
+	case required != total:
+		want = fmt.Sprintf("%d..%d", required, total)
+	}
+
+	return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `%s`: want=%s, got=%d", name, want, got)
 }
 
 // unwrapReturnValue unwraps the object to be returned
@@ -382,56 +842,249 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
-// evalIndexExpression returns value for given index
-func evalIndexExpression(left, index object.Object) object.Object {
+// evalWhileStatement runs the loop body in env for as long as the condition holds
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !IsTruthy(condition) {
+			return NULL
+		}
+
+		result := Eval(ws.Body, env)
+		if result == BREAK {
+			return NULL
+		}
+		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
+			return result
+		}
+	}
+}
+
+// evalForStatement runs the loop body once per element of the iterable, bound to the loop variable.
+// Like other blocks the body runs in env, so the variable remains bound after the loop.
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	elements, err := IterationValues(iterable)
+	if err != nil {
+		return err
+	}
+
+	for _, element := range elements {
+		env.Set(fs.Variable.Value, element)
+
+		result := Eval(fs.Body, env)
+		if result == BREAK {
+			break
+		}
+		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+// IterationValues returns the values a for loop over obj visits: the elements of an array,
+// the characters of a string or the keys of a hash
+func IterationValues(obj object.Object) ([]object.Object, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, nil
+
+	case *object.String:
+		chars := []object.Object{}
+		for _, ch := range obj.Value {
+			chars = append(chars, &object.String{Value: string(ch)})
+		}
+		return chars, nil
+
+	case *object.Hash:
+		keys := []object.Object{}
+		for _, pair := range obj.Ordered() {
+			keys = append(keys, pair.Key)
+		}
+		return keys, nil
+
+	default:
+		return nil, newError(object.TYPE_ERROR, "cannot iterate over %s", obj.Type())
+	}
+}
+
+// EvalIndex returns the element of left at the already evaluated index
+func EvalIndex(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(object.TYPE_ERROR, "index operator not supported: %s", left.Type())
 	}
 }
 
-// evalArrayIndexExpression evaluates the value at given index
+// evalArrayIndexExpression evaluates the value at given index, negative indices count from the end
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
 
-	if idx < 0 || idx > max {
-		return newError("index out of bounds!")
+	idx, ok := resolveIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+	if !ok {
+		return newError(object.INDEX_ERROR, "index out of bounds!")
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+// evalStringIndexExpression returns the char at the given index as a String.
+// Strings are indexed by Unicode char rather than by byte, negative indices count from the end.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+
+	idx, ok := resolveIndex(index.(*object.Integer).Value, len(chars))
+	if !ok {
+		return newError(object.INDEX_ERROR, "index out of bounds!")
+	}
+
+	return &object.String{Value: string(chars[idx])}
+}
+
+// resolveIndex returns the position idx refers to in a sequence of the given length,
+// counting negative indices from the end, and whether it is in bounds
+func resolveIndex(idx int64, length int) (int64, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	return idx, idx >= 0 && idx < int64(length)
+}
+
+// EvalSlice returns the elements of an Array, or chars of a String, between start and stop
+// taking every step-th one. Bounds follow Python: null bounds are omitted, negative ones count from
+// the end and out of range ones are clamped. A negative step walks backwards.
+func EvalSlice(left, start, stop, step object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		positions, err := slicePositions(int64(len(left.Elements)), start, stop, step)
+		if err != nil {
+			return err
+		}
+
+		elements := make([]object.Object, len(positions))
+		for i, pos := range positions {
+			elements[i] = left.Elements[pos]
+		}
+		return &object.Array{Elements: elements}
+
+	case *object.String:
+		chars := []rune(left.Value)
+		positions, err := slicePositions(int64(len(chars)), start, stop, step)
+		if err != nil {
+			return err
+		}
+
+		sliced := make([]rune, len(positions))
+		for i, pos := range positions {
+			sliced[i] = chars[pos]
+		}
+		return &object.String{Value: string(sliced)}
+
+	default:
+		return newError(object.TYPE_ERROR, "slice operator not supported: %s", left.Type())
+	}
+}
+
+// slicePositions returns the positions a slice with the given bounds visits in a sequence of the given length
+func slicePositions(length int64, start, stop, step object.Object) ([]int64, *object.Error) {
+	bounds := []int64{0, 0, 1}
+	given := make([]bool, 3)
+	for i, bound := range []object.Object{start, stop, step} {
+		switch bound := bound.(type) {
+		case *object.Null:
+		case *object.Integer:
+			bounds[i], given[i] = bound.Value, true
+		default:
+			return nil, newError(object.TYPE_ERROR, "slice bounds must be INTEGER, got %s", bound.Type())
+		}
+	}
+
+	by := bounds[2]
+	if by == 0 {
+		return nil, newError(object.ARGUMENT_ERROR, "slice step cannot be zero")
+	}
+
+	// walking backwards the positions run from length-1 down to just before 0
+	lower, upper := int64(0), length
+	if by < 0 {
+		lower, upper = -1, length-1
+	}
+
+	clamp := func(bound int64) int64 {
+		if bound < 0 {
+			bound += length
+		}
+		if bound < lower {
+			return lower
+		}
+		if bound > upper {
+			return upper
+		}
+		return bound
+	}
+
+	from, to := lower, upper
+	if by < 0 {
+		from, to = upper, lower
+	}
+	if given[0] {
+		from = clamp(bounds[0])
+	}
+	if given[1] {
+		to = clamp(bounds[1])
+	}
+
+	positions := []int64{}
+	for pos := from; (by > 0 && pos < to) || (by < 0 && pos > to); pos += by {
+		positions = append(positions, pos)
+
+		// stop before pos overflows on huge steps
+		if (by > 0 && to-pos <= by) || (by < 0 && to-pos >= by) {
+			break
+		}
+	}
+	return positions, nil
+}
+
 // evalHashLiteral returns the hash object
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
 		hashkey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newError(object.TYPE_ERROR, "unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashkey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashkey.HashKey(), object.HashPair{Key: key, Value: value})
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 // evalHashIndexExpression returns the value given the key
@@ -440,7 +1093,7 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newError(object.TYPE_ERROR, "unusable as hash key: %s", index.Type())
 	}
 
 	pair, ok := hashObject.Pairs[key.HashKey()]
@@ -450,3 +1103,166 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 	return pair.Value
 }
+
+// evalAssignExpression updates the variable, array element or hash entry named by the target.
+// Compound operators combine the current value with the right-hand side before storing it.
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if node.Operator != "=" {
+			current = evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+		}
+
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		if current != nil {
+			val = EvalInfix(compoundOperator(node.Operator), current, val, env.Limits())
+			if isError(val) {
+				return val
+			}
+		}
+
+		if !env.Assign(target.Value, val) {
+			return newError(object.NAME_ERROR, "cannot assign to undeclared identifier: %s", target.Value)
+		}
+		return val
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		var current object.Object
+		if node.Operator != "=" {
+			current = EvalIndex(left, index)
+			if isError(current) {
+				return current
+			}
+		}
+
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		if current != nil {
+			val = EvalInfix(compoundOperator(node.Operator), current, val, env.Limits())
+			if isError(val) {
+				return val
+			}
+		}
+
+		return EvalIndexAssign(left, index, val)
+
+	default:
+		return newError(object.TYPE_ERROR, "cannot assign to %s", node.Target.String())
+	}
+}
+
+// compoundOperator returns the infix operator a compound assignment applies, + for +=
+func compoundOperator(operator string) string {
+	return strings.TrimSuffix(operator, "=")
+}
+
+// EvalIndexAssign stores val at index in an array or hash and returns val
+func EvalIndexAssign(left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError(object.TYPE_ERROR, "array index must be INTEGER, got %s", index.Type())
+		}
+		pos, ok := resolveIndex(idx.Value, len(left.Elements))
+		if !ok {
+			return newError(object.INDEX_ERROR, "index out of bounds!")
+		}
+		left.Elements[pos] = val
+
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(object.TYPE_ERROR, "unusable as hash key: %s", index.Type())
+		}
+		left.Set(key.HashKey(), object.HashPair{Key: index, Value: val})
+
+	default:
+		return newError(object.TYPE_ERROR, "index assignment not supported: %s", left.Type())
+	}
+
+	return val
+}
+
+// evalTryExpression evaluates the try block and hands any error it returns to the catch block.
+// The finally block always runs last, a return or error from it replaces the result.
+func evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+	result := Eval(te.Block, env)
+
+	if err, ok := result.(*object.Error); ok && !err.Fatal && te.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if te.CatchParam != nil {
+			catchEnv.Set(te.CatchParam.Value, ErrorToHash(err))
+		}
+		result = Eval(te.Catch, catchEnv)
+	}
+
+	// errors from exceeding limits abort evaluation without running the finally block
+	if isFatal(result) {
+		return result
+	}
+
+	if te.Finally != nil {
+		finally := Eval(te.Finally, env)
+		if isError(finally) {
+			return finally
+		}
+		if _, ok := finally.(*object.ReturnValue); ok {
+			return finally
+		}
+	}
+
+	return result
+}
+
+// ErrorToHash returns the Hash a catch block receives for err, holding its "message" and "kind"
+func ErrorToHash(err *object.Error) *object.Hash {
+	kind := err.Kind
+	if kind == "" {
+		kind = object.THROWN_ERROR
+	}
+
+	hash := object.NewHash()
+	for _, field := range [][2]string{{"message", err.Message}, {"kind", kind}} {
+		k := &object.String{Value: field[0]}
+		hash.Set(k.HashKey(), object.HashPair{Key: k, Value: &object.String{Value: field[1]}})
+	}
+
+	return hash
+}
+
+// ThrownError returns the Error raised by throwing val.
+// Hashes with a "message" (and optionally "kind") key, like the ones caught errors are bound to, are re-thrown as that error.
+func ThrownError(val object.Object) *object.Error {
+	if hash, ok := val.(*object.Hash); ok {
+		if message, ok := hash.Pairs[(&object.String{Value: "message"}).HashKey()]; ok {
+			kind := object.THROWN_ERROR
+			if k, ok := hash.Pairs[(&object.String{Value: "kind"}).HashKey()]; ok {
+				kind = k.Value.Inspect()
+			}
+			return newError(kind, "%s", message.Value.Inspect())
+		}
+	}
+
+	return newError(object.THROWN_ERROR, "%s", val.Inspect())
+}
@@ -1,6 +1,9 @@
 package evaluator
 
 import (
+	"context"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
@@ -28,6 +31,15 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 10 % 4 * 3", 8},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"0 ** 0", 1},
 	}
 
 	for _, tt := range tests {
@@ -36,6 +48,51 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2},
+		{"7 / 2.0", 3.5},
+		{"10.0 - 2.5 * 2", 5},
+		{"7.5 % 2", 1.5},
+		{"2 ** 0.5 ** 2", 1.189207115002721},
+		{"2 ** -1", 0.5},
+		{"2.0 ** 3", 8},
+		{"let x = 1; x += 0.25; x", 1.25},
+		{"1e3 / 8", 125},
+	}
+
+	for _, tt := range tests {
+		testFloatObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"2.0", "2.0"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1e21", "1e+21"},
+		{"-0.0", "-0.0"},
+		{"[1, 1.0]", "[1, 1.0]"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -60,6 +117,50 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 0", true},
+		{`"" && []`, true},
+		{"1 < 2 && 2 < 3 || false", true},
+		{"false && x", false},
+		{"true || x", true},
+		{"let f = fn() { throw \"called\" }; true || f()", true},
+		{"1.5 < 2", true},
+		{"2 > 2.5", false},
+		{"1 == 1.0", true},
+		{"0.1 + 0.2 == 0.3", false},
+		{"2.5 >= 2.5", true},
+		{"1.0 != 1", false},
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"ab" <= "ab"`, true},
+		{`"Z" >= "a"`, false},
+		{`"é" > "z"`, true},
+		{`"1" == 1`, false},
+		{`"a" != 1`, true},
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1] == [1, 2]", false},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{`{"a": [1], "b": 2} == {"b": 2, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{} == []`, false},
+		{"let f = fn() { 1 }; f == f", true},
+		{"fn() { 1 } == fn() { 1 }", false},
+		{"let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b", true},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +251,26 @@ func TestErrorHandling(t *testing.T) {
 			"true + false;",
 			"unknown operator: BOOLEAN + BOOLEAN",
 		},
+		{
+			"true <= false",
+			"unknown operator: BOOLEAN <= BOOLEAN",
+		},
+		{
+			"1.5 / 0",
+			"division by zero",
+		},
+		{
+			"-true + 1.5",
+			"unknown operator: -BOOLEAN",
+		},
+		{
+			"1.5 + true",
+			"type mismatch: FLOAT + BOOLEAN",
+		},
+		{
+			"true && 1 + true",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
 		{
 			"5; true + false; 5",
 			"unknown operator: BOOLEAN + BOOLEAN",
@@ -175,7 +296,7 @@ func TestErrorHandling(t *testing.T) {
 		},
 		{
 			`"Hello" - "World"`,
-			"unkown operator: STRING - STRING",
+			"unknown operator: STRING - STRING",
 		},
 		{
 			`{"name": "Monkey"}[fn(x) { x }];`,
@@ -197,6 +318,295 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorTraceback(t *testing.T) {
+	input := `let inner = fn(x) {
+	x + missing;
+};
+let outer = fn() {
+	inner(1);
+};
+outer();`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Pos.String() != "2:6" {
+		t.Errorf("wrong error position. got=%q", errObj.Pos)
+	}
+
+	expectedTrace := []struct {
+		function string
+		callSite string
+	}{
+		{"inner", "5:2"},
+		{"outer", "7:1"},
+	}
+
+	if len(errObj.Trace) != len(expectedTrace) {
+		t.Fatalf("wrong number of trace frames. got=%d (%+v)", len(errObj.Trace), errObj.Trace)
+	}
+
+	for i, expected := range expectedTrace {
+		frame := errObj.Trace[i]
+		if frame.Function != expected.function {
+			t.Errorf("frame %d has wrong function. expected=%q, got=%q", i, expected.function, frame.Function)
+		}
+		if frame.CallSite.String() != expected.callSite {
+			t.Errorf("frame %d has wrong call site. expected=%q, got=%q", i, expected.callSite, frame.CallSite)
+		}
+	}
+
+	expectedTraceback := `ERROR: identifier not found: missing
+    at inner (2:6)
+    at outer (5:2)
+    at <program> (7:1)`
+
+	if errObj.Traceback() != expectedTraceback {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expectedTraceback, errObj.Traceback())
+	}
+}
+
+func TestLimits(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input           string
+		limits          object.Limits
+		expectedKind    string
+		expectedMessage string
+	}{
+		{
+			"let f = fn() { f() }; f();",
+			object.Limits{MaxDepth: DefaultMaxDepth},
+			object.CALL_DEPTH_ERROR,
+			"maximum call depth exceeded: 10000",
+		},
+		{
+			"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(10);",
+			object.Limits{MaxDepth: 5},
+			object.CALL_DEPTH_ERROR,
+			"maximum call depth exceeded: 5",
+		},
+		{
+			"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(1000);",
+			object.Limits{MaxSteps: 100},
+			object.STEP_LIMIT_ERROR,
+			"step limit exceeded: 100",
+		},
+		{
+			"1 + 1",
+			object.Limits{Context: canceled},
+			object.CANCELED_ERROR,
+			"evaluation canceled: context canceled",
+		},
+		{
+			"let f = fn() { f() }; try { f() } catch (e) { 1 }",
+			object.Limits{MaxDepth: 10},
+			object.CALL_DEPTH_ERROR,
+			"maximum call depth exceeded: 10",
+		},
+		{
+			"let f = fn() { try { f() } finally { return 1; } }; f()",
+			object.Limits{MaxDepth: 10},
+			object.CALL_DEPTH_ERROR,
+			"maximum call depth exceeded: 10",
+		},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		limits := tt.limits
+		env.SetLimits(&limits)
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Kind != tt.expectedKind || errObj.Message != tt.expectedMessage {
+			t.Errorf("%q: expected %s %q, got %s %q", tt.input, tt.expectedKind, tt.expectedMessage, errObj.Kind, errObj.Message)
+		}
+		if limits.Depth != 0 {
+			t.Errorf("%q: call depth not unwound. got=%d", tt.input, limits.Depth)
+		}
+	}
+}
+
+func TestLimitsAllowNormalEvaluation(t *testing.T) {
+	input := "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(100);"
+
+	env := object.NewEnvironment()
+	env.SetLimits(&object.Limits{MaxDepth: 101, MaxSteps: 10000, Context: context.Background()})
+
+	testIntegerObject(t, Eval(parser.New(lexer.New(input)).ParseProgram(), env), 100)
+}
+
+func TestRecursionTracebackIsCollapsed(t *testing.T) {
+	input := `let f = fn(n) {
+	if (n == 0) { missing } else { f(n - 1) }
+};
+f(50);`
+
+	errObj, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+
+	expectedTraceback := `ERROR: identifier not found: missing
+    at f (2:16)
+    at f (2:33)
+    ... repeated 49 more times
+    at <program> (4:1)`
+
+	if errObj.Traceback() != expectedTraceback {
+		t.Errorf("wrong traceback.\nexpected=%q\ngot=%q", expectedTraceback, errObj.Traceback())
+	}
+	if lines := strings.Count(errObj.Traceback(), "\n"); lines != 4 {
+		t.Errorf("expected 5 traceback lines. got=%d", lines+1)
+	}
+}
+
+func TestTryCatchFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 + true } catch (e) { 2 }`, 2},
+		{`try { throw "boom"; 1 } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom"; } catch (e) { e["kind"] }`, "Error"},
+		{`try { missing } catch (e) { e["kind"] }`, "NameError"},
+		{`try { [1][5] } catch (e) { e["kind"] }`, "IndexError"},
+		{`try { -true } catch (e) { e["message"] }`, "unknown operator: -BOOLEAN"},
+		{`try { len(1, 2) } catch (e) { e["kind"] }`, "ArgumentError"},
+		{`try { throw {"kind": "ValueError", "message": "bad"} } catch (e) { e["kind"] + ": " + e["message"] }`, "ValueError: bad"},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e["message"] }`, "inner"},
+		{`let f = fn() { throw "deep"; }; try { f() } catch { 7 }`, 7},
+		{`let x = 1; try { let x = 2; } finally { let x = 3; }; x`, 3},
+		{`let f = fn() { try { return 1; } finally { 2 } }; f()`, 1},
+		{`let f = fn() { try { throw "x" } finally { return 5; } }; f()`, 5},
+		{`let f = fn() { try { throw "x" } catch (e) { return e["message"]; }; 10 }; f()`, "x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%q: String has wrong value. expected=%q, got=%q", tt.input, expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedKind    string
+	}{
+		{`throw "boom"; 5`, "boom", "Error"},
+		{`throw 5`, "5", "Error"},
+		{`try { throw "a" } finally { 1 }`, "a", "Error"},
+		{`try { 1 } finally { throw "b" }`, "b", "Error"},
+		{`try { throw "a" } catch (e) { throw "c" }`, "c", "Error"},
+		{`try { 1 + true } finally { 1 }`, "type mismatch: INTEGER + BOOLEAN", "TypeError"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+		if errObj.Kind != tt.expectedKind {
+			t.Errorf("%q: wrong error kind. expected=%q, got=%q", tt.input, tt.expectedKind, errObj.Kind)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []string{
+		"1 / 0",
+		"let zero = 5 - 5; 10 / zero",
+		"let f = fn(x) { 100 / x }; f(0)",
+		"5 % 0",
+	}
+
+	for _, input := range tests {
+		evaluated := testEval(input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != "division by zero" || errObj.Kind != object.ARITHMETIC_ERROR {
+			t.Errorf("%q: wrong error. got=%s %q", input, errObj.Kind, errObj.Message)
+		}
+	}
+}
+
+func TestIntegerOverflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		wrapped  int64
+		errorMsg string
+	}{
+		{"9223372036854775807 + 1", math.MinInt64, "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", math.MaxInt64, "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", math.MinInt64, "integer overflow: 4611686018427387904 * 2"},
+		{"let min = -9223372036854775807 - 1; min / -1", math.MinInt64, "integer overflow: -9223372036854775808 / -1"},
+		{"let min = -9223372036854775807 - 1; -min", math.MinInt64, "integer overflow: -(-9223372036854775808)"},
+		{"let min = -9223372036854775807 - 1; -1 * min", math.MinInt64, "integer overflow: -1 * -9223372036854775808"},
+		{"2 ** 63", math.MinInt64, "integer overflow: 2 ** 63"},
+		{"3 ** 41", -420491770248316829, "integer overflow: 3 ** 41"},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.wrapped)
+	}
+
+	checked := func(input string) object.Object {
+		env := object.NewEnvironment()
+		env.SetLimits(&object.Limits{CheckOverflow: true})
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+
+	for _, tt := range tests {
+		evaluated := checked(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.errorMsg {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.errorMsg, errObj.Message)
+		}
+	}
+
+	testIntegerObject(t, checked("9223372036854775806 + 1"), math.MaxInt64)
+	testIntegerObject(t, checked("-3037000499 * 3037000499"), -9223372030926249001)
+	testIntegerObject(t, checked("(-2) ** 63"), math.MinInt64)
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -253,6 +663,50 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let add = fn(x, y = 10) { x + y; }; add(5);", 15},
+		{"let add = fn(x, y = 10) { x + y; }; add(5, 1);", 6},
+		{"let f = fn(x, y = x * 2) { y; }; f(4);", 8},
+		{"let count = fn(...args) { len(args); }; count();", 0},
+		{"let count = fn(...args) { len(args); }; count(1, 2, 3);", 3},
+		{"let f = fn(x, ...rest) { x + rest[1]; }; f(1, 2, 3);", 4},
+		{"let f = fn(x, y = 2, ...rest) { x + y + len(rest); }; f(1);", 3},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestWrongNumberOfArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(x, y) { x + y; }; add(1);", "wrong number of arguments to `add`: want=2, got=1"},
+		{"let add = fn(x, y) { x + y; }; add(1, 2, 3);", "wrong number of arguments to `add`: want=2, got=3"},
+		{"fn() { 1; }(1);", "wrong number of arguments to `<anonymous>`: want=0, got=1"},
+		{"let f = fn(x, y = 1) { x; }; f();", "wrong number of arguments to `f`: want=1..2, got=0"},
+		{"let f = fn(x, ...rest) { x; }; f();", "wrong number of arguments to `f`: want=1+, got=0"},
+		{"let f = fn(x = y) { x; }; f();", "identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned", tt.input)
+			continue
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 let newAdder = fn(x) {
@@ -265,6 +719,136 @@ addTwo(2);`
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { let i = i + 1; }; i", 5},
+		{"let i = 0; while (true) { let i = i + 1; if (i == 3) { break; } }; i", 3},
+		{"let i = 0; let n = 0; while (i < 5) { let i = i + 1; if (i == 2) { continue; } let n = n + i; }; n", 13},
+		{"while (false) { 1 }", nil},
+		{"let sum = fn(arr) { let total = 0; for (x in arr) { let total = total + x; }; total }; sum([1, 2, 3, 4])", 10},
+		{`let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10; } } }; f()`, 20},
+		{`let count = fn(s) { let n = 0; for (c in s) { let n = n + 1; }; n }; count("héllo")`, 5},
+		{`let f = fn(h) { let out = ""; for (k in h) { let out = out + k; }; out }; f({"b": 1, "a": 2, "c": 3})`, "bac"},
+		{`let f = fn() { let n = 0; for (c in "abcd") { if (n == 2) { break; } let last = c; let n = n + 1; }; last }; f()`, "b"},
+		{`let f = fn() { let n = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } let n = n + x; }; n }; f()`, 8},
+		{`for (x in []) { 1 }`, nil},
+		{`let x = 1; for (x in [5, 6]) { }; x`, 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%q: String has wrong value. got=%q, want=%q", tt.input, str.Value, expected)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"for (x in 5) { x }", "cannot iterate over INTEGER"},
+		{"while (1 + true) { 1 }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (x in [1, 2]) { x + true }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 5; x", 5},
+		{"let x = 1; x = 5", 5},
+		{"let x = 1; let y = 2; x = y = 7; x + y", 14},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let n = 0; let inc = fn() { n += 1 }; inc(); inc(); n", 2},
+		{"let n = 0; let f = fn() { let n = 5; n = 6; n }; f() + n", 6},
+		{"let i = 0; let total = 0; while (i < 4) { i += 1; total += i; }; total", 10},
+		{"let arr = [1, 2, 3]; arr[1] = 20; arr[1]", 20},
+		{"let arr = [1, 2, 3]; arr[2] *= 10; arr[2]", 30},
+		{"let arr = [1, 2, 3]; arr[-1] += 7; arr[2]", 10},
+		{"let arr = [[1], [2]]; arr[1][0] += 5; arr[1][0]", 7},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] += 10; h["a"] + h["b"]`, 13},
+		{"let arr = [1]; let set = fn(a) { a[0] = 9 }; set(arr); arr[0]", 9},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%q: String has wrong value. got=%q, want=%q", tt.input, str.Value, expected)
+			}
+		}
+	}
+}
+
+func TestAssignErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedKind string
+		expectedMsg  string
+	}{
+		{"x = 5", object.NAME_ERROR, "cannot assign to undeclared identifier: x"},
+		{"let f = fn() { y = 1 }; f()", object.NAME_ERROR, "cannot assign to undeclared identifier: y"},
+		{"x += 1", object.NAME_ERROR, "identifier not found: x"},
+		{"let x = 1; x += true", object.TYPE_ERROR, "type mismatch: INTEGER + BOOLEAN"},
+		{"let arr = [1]; arr[1] = 2", object.INDEX_ERROR, "index out of bounds!"},
+		{"let arr = [1]; arr[-2] = 2", object.INDEX_ERROR, "index out of bounds!"},
+		{`let arr = [1]; arr["a"] = 2`, object.TYPE_ERROR, "array index must be INTEGER, got STRING"},
+		{"let h = {}; h[fn() { 1 }] = 2", object.TYPE_ERROR, "unusable as hash key: FUNCTION"},
+		{`let h = {}; h["a"] += 1`, object.TYPE_ERROR, "type mismatch: NULL + INTEGER"},
+		{`let s = "ab"; s[0] = "c"`, object.TYPE_ERROR, "index assignment not supported: STRING"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned", tt.input)
+			continue
+		}
+		if errObj.Kind != tt.expectedKind || errObj.Message != tt.expectedMsg {
+			t.Errorf("%q: expected %s %q, got %s %q", tt.input, tt.expectedKind, tt.expectedMsg, errObj.Kind, errObj.Message)
+		}
+	}
+}
+
 func TestStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
 
@@ -279,6 +863,99 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\tb"`, "a\tb"},
+		{`"line\n" + "next"`, "line\nnext"},
+		{`"\"quoted\" \\ done"`, `"quoted" \ done`},
+		{`"caf\u{e9}"`, "café"},
+		{"`C:\\path\\n`", `C:\path\n`},
+		{"`two\nlines`", "two\nlines"},
+		{`let größe = "groß"; größe`, "groß"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: String has wrong value. got=%q, want=%q", tt.input, str.Value, tt.expected)
+		}
+	}
+}
+
+func TestTemplateStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Ann"; let age = 30; "Hello ${name}, you are ${age + 1}"`, "Hello Ann, you are 31"},
+		{`"${1}${2}"`, "12"},
+		{`"list: ${[1, "two", 3.0]} ${true} ${if (false) { 1 }}"`, "list: [1, two, 3.0] true null"},
+		{`let f = fn(x) { "<${x}>" }; "${f("${f(1)}")}"`, "<<1>>"},
+		{`"braces ${ {"a": 1}["a"] } kept"`, "braces 1 kept"},
+		{`"costs \${5}"`, "costs ${5}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: String has wrong value. got=%q, want=%q", tt.input, str.Value, tt.expected)
+		}
+	}
+
+	errObj, ok := testEval(`"a ${1 + true} b"`).(*object.Error)
+	if !ok || errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("expected type mismatch error from interpolation. got=%v", errObj)
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc"[0]`, "a"},
+		{`"héllo"[1]`, "é"},
+		{`let s = "日本語"; s[len(s) - 1]`, "語"},
+		{`"abc"[3]`, "index out of bounds!"},
+		{`"abc"[-1]`, "c"},
+		{`"héllo"[-4]`, "é"},
+		{`"abc"[-4]`, "index out of bounds!"},
+		{`"abc"["a"]`, "index operator not supported: STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			if errObj.Message != tt.expected {
+				t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+			}
+			continue
+		}
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%q: String has wrong value. got=%q, want=%q", tt.input, str.Value, tt.expected)
+		}
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
@@ -301,6 +978,9 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("héllo")`, 5},
+		{`len("日本語")`, 3},
+		{`len("\u{1F600}")`, 1},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 		{`len([1, 2, 3])`, 3},
@@ -316,6 +996,69 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`rest([])`, nil},
 		{`push([], 1)`, []int{1}},
 		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(" 42 ")`, 42},
+		{`int(7)`, 7},
+		{`int("4.5")`, `cannot convert "4.5" to INTEGER`},
+		{`int(1e19)`, "cannot convert 1e+19 to INTEGER"},
+		{`int(true)`, "argument to `int` not supported, got BOOLEAN"},
+		{`float(2)`, 2.0},
+		{`float("2.5e1")`, 25.0},
+		{`float("abc")`, `cannot convert "abc" to FLOAT`},
+		{`round(2.5)`, 3},
+		{`round(-2.5)`, -3},
+		{`round(2.4)`, 2},
+		{`round(5)`, 5},
+		{`round(3.14159, 2)`, 3.14},
+		{`round(1234, -2)`, 1200.0},
+		{`round("1")`, "argument to `round` must be INTEGER or FLOAT, got STRING"},
+		{`round(1.5, 2, 3)`, "wrong number of arguments. got=3, want=1..2"},
+		{`floor(2.7)`, 2},
+		{`floor(-2.1)`, -3},
+		{`ceil(2.1)`, 3},
+		{`ceil(-2.7)`, -2},
+		{`ceil([])`, "argument to `ceil` must be INTEGER or FLOAT, got ARRAY"},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`len({})`, 0},
+		{`keys([1])`, "argument to `keys` must be HASH, got ARRAY"},
+		{`values({}, {})`, "wrong number of arguments. got=2, want=1"},
+		{`has({"a": 1}, "a")`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`has({1: 1}, 1.0)`, true},
+		{`has({}, [])`, "unusable as hash key: ARRAY"},
+		{`has([], 1)`, "argument to `has` must be HASH, got ARRAY"},
+		{`delete({"a": 1}, fn(x) { x })`, "unusable as hash key: FUNCTION"},
+		{`merge({}, 1)`, "argument to `merge` must be HASH, got INTEGER"},
+		{`merge({})`, "wrong number of arguments. got=1, want=2"},
+		{`map([1], 1)`, "argument to `map` must be FUNCTION, got INTEGER"},
+		{`filter("abc", len)`, "argument to `filter` must be ARRAY, got STRING"},
+		{`map([1, 2], fn(x, y) { x })`, "wrong number of arguments to `<anonymous>`: want=2, got=1"},
+		{`map([1, "a"], fn(x) { x * 2 })`, "type mismatch: STRING * INTEGER"},
+		{`reduce([], fn(a, b) { a + b })`, "`reduce` of an empty array needs an initial value"},
+		{`reduce([1], fn(a, b) { a + b }, 0, 1)`, "wrong number of arguments. got=4, want=2..3"},
+		{`sort([2, "a", 1])`, "type mismatch: STRING < INTEGER"},
+		{`sort([1], "desc")`, "argument to `sort` must be FUNCTION, got STRING"},
+		{`any([1], 2)`, "argument to `any` must be FUNCTION, got INTEGER"},
+		{`all({})`, "argument to `all` must be ARRAY, got HASH"},
+		{`zip([1], "a")`, "argument to `zip` must be ARRAY, got STRING"},
+		{`range(1.5)`, "argument to `range` must be INTEGER, got FLOAT"},
+		{`range(1, 5, 0)`, "`range` step cannot be zero"},
+		{`range(1000000000000)`, "`range` of 1000000000000 elements exceeds the limit of 10000000"},
+		{`range()`, "wrong number of arguments. got=0, want=1..3"},
+		{`reverse(5)`, "argument to `reverse` not supported, got INTEGER"},
+		{`help("f")`, "argument to `help` must be a function, got STRING"},
+		{`help()`, "wrong number of arguments. got=0, want=1"},
+		{`reduce([1, 2, 3], fn(a, b) { a + b })`, 6},
+		{`reduce([1, 2, 3], fn(a, b) { a + b }, 10)`, 16},
+		{`reduce([], fn(a, b) { a + b }, 0)`, 0},
+		{`find([1, 2, 3, 4], fn(x) { x > 2 })`, 3},
+		{`any([1, 2], fn(x) { x > 1 })`, true},
+		{`any([], fn(x) { true })`, false},
+		{`any([false, {}["a"]])`, false},
+		{`all([1, 2], fn(x) { x > 1 })`, false},
+		{`all([])`, true},
+		{`all([1, "a", []])`, true},
 	}
 
 	for _, tt := range tests {
@@ -325,6 +1068,10 @@ func TestBuiltinFunctions(t *testing.T) {
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
 		case string:
 			errObj, ok := evaluated.(*object.Error)
 			if !ok {
@@ -402,6 +1149,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			"index out of bounds!",
 		},
 	}
@@ -425,6 +1180,98 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:-2]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4, 5][::2]", "[1, 3, 5]"},
+		{"[1, 2, 3, 4, 5][1::2]", "[2, 4]"},
+		{"[1, 2, 3, 4, 5][::-1]", "[5, 4, 3, 2, 1]"},
+		{"[1, 2, 3, 4, 5][3:0:-1]", "[4, 3, 2]"},
+		{"[1, 2, 3, 4, 5][-1:-4:-2]", "[5, 3]"},
+		{"[1, 2, 3][1:100]", "[2, 3]"},
+		{"[1, 2, 3][-100:1]", "[1]"},
+		{"[1, 2, 3][2:1]", "[]"},
+		{"[1, 2, 3][0:3:9223372036854775807]", "[1]"},
+		{"[][::-1]", "[]"},
+		{"let a = [1, 2, 3]; let b = a[:]; b[0] = 9; [a, b]", "[[1, 2, 3], [9, 2, 3]]"},
+		{`"héllo wörld"[1:4]`, "éll"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"hello"[-3:]`, "llo"},
+		{`let i = 1; "hello"[i + 1:i * 4]`, "ll"},
+		{"[1, 2, 3][1:2:0]", "slice step cannot be zero"},
+		{`[1, 2, 3]["a":]`, "slice bounds must be INTEGER, got STRING"},
+		{"[1, 2, 3][:1.5]", "slice bounds must be INTEGER, got FLOAT"},
+		{`{"a": 1}[0:1]`, "slice operator not supported: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		switch result := evaluated.(type) {
+		case *object.Error:
+			got = result.Message
+		default:
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNullSafeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"null == null", "true"},
+		{`[null == false, null == 0, null == {}["a"]]`, "[false, false, true]"},
+		{"null ?? 5", "5"},
+		{"0 ?? 5", "0"},
+		{"false ?? 5", "false"},
+		{"null ?? null ?? 3", "3"},
+		{`let calls = 0; let f = fn() { calls += 1 }; 1 ?? f(); calls`, "0"},
+		{`let config = {"db": {"port": 5432}}; config["db"]?.["port"]`, "5432"},
+		{`let config = {"db": {"port": 5432}}; config["cache"]?.["port"] ?? 6379`, "6379"},
+		{`let h = {"a": {"b": {"c": 1}}}; [h?.["a"]?.["b"]?.["c"], h?.["x"]?.["b"]?.["c"]]`, "[1, null]"},
+		{`let calls = 0; let key = fn() { calls += 1; "k" }; null?.[key()]; calls`, "0"},
+		{`null?.[1:]`, "null"},
+		{`"abc"?.[1:]`, "bc"},
+		{`let f = null; f?.(1, 2)`, "null"},
+		{`let f = fn(x) { x * 2 }; f?.(4)`, "8"},
+		{`let calls = 0; let arg = fn() { calls += 1 }; null?.(arg()); calls`, "0"},
+		{`let h = {"on": fn() { "called" }}; [h["on"]?.(), h["off"]?.()]`, "[called, null]"},
+		{`null["a"]`, "index operator not supported: NULL"},
+		{`5?.[0]`, "index operator not supported: INTEGER"},
+		{`null(1)`, "not a function: NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		var got string
+		switch result := evaluated.(type) {
+		case *object.Error:
+			got = result.Message
+		default:
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -465,6 +1312,111 @@ func TestHashLiterals(t *testing.T) {
 	}
 }
 
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, 10: 3, true: 4}`, "{b:1, a:2, 10:3, true:4}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a:3, b:2}"},
+		{`let h = {"z": 1, "y": 2}; h["x"] = 3; h["z"] = 4; h`, "{z:4, y:2, x:3}"},
+		{`let log = []; let f = fn(x) { log = push(log, x); x }; {f("k1"): f(1), f("k2"): f(2)}; log`, "[k1, 1, k2, 2]"},
+		{`let out = []; for (k in {3: 0, 1: 0, 2: 0}) { out = push(out, k); }; out`, "[3, 1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`entries({"b": 1, "a": [2]})`, "[[b, 1], [a, [2]]]"},
+		{`keys({})`, "[]"},
+		{`delete({"a": 1, "b": 2, "c": 3}, "b")`, "{a:1, c:3}"},
+		{`delete({"a": 1}, "z")`, "{a:1}"},
+		{`let h = {"a": 1, "b": 2}; let d = delete(h, "a"); [h, d]`, "[{a:1, b:2}, {b:2}]"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a:1, b:3, c:4}"},
+		{`let h = {"a": 1}; let m = merge(h, {"b": 2}); m["a"] = 5; [h, m]`, "[{a:1}, {a:5, b:2}]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map(["a", "bc"], len)`, "[1, 2]"},
+		{`let n = 10; map([1, 2], fn(x) { x + n })`, "[11, 12]"},
+		{`filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })`, "[2, 4]"},
+		{`reduce(["a", "b", "c"], fn(acc, s) { acc + s })`, "abc"},
+		{`let a = [3, 1, 2]; let s = sort(a); [a, s]`, "[[3, 1, 2], [1, 2, 3]]"},
+		{`sort(["pear", "fig", "apple"])`, "[apple, fig, pear]"},
+		{`sort([1, 3, 2], fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`sort([[2, "b"], [1, "a"], [2, "a"], [1, "b"]], fn(x, y) { x[0] < y[0] })`, "[[1, a], [1, b], [2, b], [2, a]]"},
+		{`find([1, 2], fn(x) { x > 5 })`, "null"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`range(4)`, "[0, 1, 2, 3]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(10, 0, -3)`, "[10, 7, 4, 1]"},
+		{`range(5, 2)`, "[]"},
+		{`range(9223372036854775806, 9223372036854775807, 5)`, "[9223372036854775806]"},
+		{`range(-9223372036854775807 - 1, 9223372036854775807, 4611686018427387904)`, "[-9223372036854775808, -4611686018427387904, 0, 4611686018427387904]"},
+		{`range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1)`, "[9223372036854775807, -1]"},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse("héllo")`, "olléh"},
+		{`let calls = 0; any([1, 2, 3], fn(x) { calls += 1; x == 2 }); calls`, "2"},
+		{`map([[1, 2], [3]], fn(xs) { reduce(map(xs, fn(x) { x * x }), fn(a, b) { a + b }, 0) })`, "[5, 9]"},
+		{`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; map(range(1, 6), fact)`, "[1, 2, 6, 24, 120]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// add returns the sum of a and b\nlet add = fn(a, b) { a + b }; help(add)", "add returns the sum of a and b"},
+		{"/*\n * Greets someone.\n * Returns a string.\n */\nlet greet = fn(n) { \"hi \" + n }; help(greet)", "Greets someone.\nReturns a string."},
+		{"// one\n// two\nlet f = fn() { 1 }; help(f)", "one\ntwo"},
+		{"// not about f\n\nlet f = fn() { 1 }; help(f)", "null"},
+		{"let f = fn() { 1 }; // trailing\nhelp(f)", "null"},
+		{"// a doc\nlet f = fn() { 1 }; let g = f; help(g)", "a doc"},
+		{"help(fn() { 1 })", "null"},
+		{"help(len)", "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
 func TestHashIndexExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -498,6 +1450,18 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{1.5: 5}[1.5]`,
+			5,
+		},
+		{
+			`{1: 5}[1.0]`,
+			5,
+		},
+		{
+			`{2.0: 5}[2]`,
+			5,
+		},
 	}
 
 	for _, tt := range tests {
@@ -535,6 +1499,21 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	result, ok := obj.(*object.Boolean)
 	if !ok {
@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+)
+
+// DefineMacros moves the macros defined at the top level of program, with let name = macro(...),
+// out of the program and into env, where ExpandMacros looks them up
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := program.Statements[:0]
+
+	for _, statement := range program.Statements {
+		let, ok := statement.(*ast.LetStatement)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+		literal, ok := let.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		env.Set(let.Name.Value, &object.Macro{
+			Name:       let.Name.Value,
+			Parameters: literal.Parameters,
+			Body:       literal.Body,
+			Env:        env,
+		})
+	}
+
+	program.Statements = statements
+}
+
+// ExpandMacros returns program with every call to a macro defined in env replaced by the code the macro
+// returns for it. Macros get their arguments unevaluated, as quotes, and must return a quote.
+// Calls inside the arguments are expanded first, the code a macro returns is not expanded again.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	// the first error stops any further expansion
+	var err *object.Error
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || err != nil {
+			return node
+		}
+		macro, ok := lookupMacro(call, env)
+		if !ok {
+			return node
+		}
+
+		expansion, expandErr := expandMacroCall(macro, call)
+		if expandErr != nil {
+			err = expandErr
+			return node
+		}
+		return expansion
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded, nil
+}
+
+// lookupMacro returns the macro called by call, if it calls one by name
+func lookupMacro(call *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+// expandMacroCall evaluates the body of macro with its parameters bound to the quoted arguments of call
+func expandMacroCall(macro *object.Macro, call *ast.CallExpression) (ast.Node, *object.Error) {
+	if len(call.Arguments) != len(macro.Parameters) {
+		err := ArgumentCountError(macro.Name, len(macro.Parameters), len(macro.Parameters), false, len(call.Arguments))
+		err.Pos = call.Pos()
+		return nil, err
+	}
+
+	env := object.NewEnclosedEnvironment(macro.Env)
+	for i, param := range macro.Parameters {
+		env.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+	}
+
+	evaluated := unwrapReturnValue(Eval(macro.Body, env))
+	if err, ok := evaluated.(*object.Error); ok {
+		err.Trace = append(err.Trace, object.TraceFrame{Function: macro.Name, CallSite: call.Pos()})
+		return nil, err
+	}
+
+	quote, ok := evaluated.(*object.Quote)
+	if !ok {
+		// an empty body evaluates to nothing
+		got := object.ObjectType(object.NULL_OBJ)
+		if evaluated != nil {
+			got = evaluated.Type()
+		}
+		err := newError(object.MACRO_ERROR, "macro `%s` must return a QUOTE, got %s", macro.Name, got)
+		err.Pos = call.Pos()
+		return nil, err
+	}
+
+	return quote.Node, nil
+}
@@ -0,0 +1,154 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	for _, name := range []string{"number", "function"} {
+		if _, ok := env.Get(name); ok {
+			t.Errorf("%s defined in environment", name)
+		}
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment.")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if macro.Name != "mymacro" {
+		t.Errorf("wrong macro name. got=%q", macro.Name)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("wrong number of macro parameters. got=%d", len(macro.Parameters))
+	}
+
+	if macro.Parameters[0].String() != "x" || macro.Parameters[1].String() != "y" {
+		t.Errorf("wrong parameters. got=%q, %q", macro.Parameters[0], macro.Parameters[1])
+	}
+
+	if macro.Body.String() != "(x + y)" {
+		t.Errorf("body is not %q. got=%q", "(x + y)", macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let infixExpression = macro() { quote(1 + 2); };
+			infixExpression();
+			`,
+			`(1 + 2)`,
+		},
+		{
+			`
+			let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(2 + 2, 10 - 5);
+			`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`
+			let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));
+			`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			// calls in the arguments are expanded before the call they are passed to
+			`
+			let double = macro(x) { quote(unquote(x) * 2) };
+			let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(double(1), 3);
+			`,
+			`3 - (1 * 2)`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("expansion error: %s", err.Message)
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = macro(x) { x + 1 }; m(1)`, "type mismatch: QUOTE + INTEGER"},
+		{`let m = macro(x) { 1 }; m(1)`, "macro `m` must return a QUOTE, got INTEGER"},
+		{`let m = macro(x) { }; m(1)`, "macro `m` must return a QUOTE, got NULL"},
+		{`let m = macro(x, y) { quote(x) }; m(1)`, "wrong number of arguments to `m`: want=2, got=1"},
+		{`let m = macro(x) { throw "no" }; m(1)`, "no"},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("%q: expected an expansion error", tt.input)
+			continue
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
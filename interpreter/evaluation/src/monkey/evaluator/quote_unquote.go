@@ -0,0 +1,118 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+)
+
+// isCallTo reports whether call calls the function named name
+func isCallTo(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+// evalQuote returns the argument of a quote call as code instead of evaluating it.
+// Calls to unquote inside it are evaluated and replaced by the code for their value.
+func evalQuote(call *ast.CallExpression, env *object.Environment) object.Object {
+	if len(call.Arguments) != 1 {
+		return ArgumentCountError("quote", 1, 1, false, len(call.Arguments))
+	}
+
+	// the first error stops any further unquoting
+	var err *object.Error
+	quoted := ast.Modify(call.Arguments[0], func(node ast.Node) ast.Node {
+		unquote, ok := node.(*ast.CallExpression)
+		if !ok || !isCallTo(unquote, "unquote") || err != nil {
+			return node
+		}
+
+		if len(unquote.Arguments) != 1 {
+			err = ArgumentCountError("unquote", 1, 1, false, len(unquote.Arguments))
+			err.Pos = unquote.Pos()
+			return node
+		}
+
+		value := Eval(unquote.Arguments[0], env)
+		if valueErr, ok := value.(*object.Error); ok {
+			err = valueErr
+			return node
+		}
+
+		converted, convertErr := objectToNode(value, unquote)
+		if convertErr != nil {
+			err = convertErr
+			err.Pos = unquote.Pos()
+			return node
+		}
+		return converted
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Quote{Node: quoted}
+}
+
+// objectToNode converts obj back into the code for it, positioned where the code it replaces was
+func objectToNode(obj object.Object, at ast.Node) (ast.Expression, *object.Error) {
+	tok := func(tokenType token.TokenType, literal string) token.Token {
+		return token.Token{Type: tokenType, Literal: literal, Pos: at.Pos(), End: at.End()}
+	}
+
+	switch obj := obj.(type) {
+
+	case *object.Integer:
+		return &ast.IntegerLiteral{Token: tok(token.INT, fmt.Sprintf("%d", obj.Value)), Value: obj.Value}, nil
+
+	case *object.Float:
+		return &ast.FloatLiteral{Token: tok(token.FLOAT, obj.Inspect()), Value: obj.Value}, nil
+
+	case *object.Boolean:
+		if obj.Value {
+			return &ast.Boolean{Token: tok(token.TRUE, "true"), Value: true}, nil
+		}
+		return &ast.Boolean{Token: tok(token.FALSE, "false"), Value: false}, nil
+
+	case *object.Null:
+		return &ast.NullLiteral{Token: tok(token.NULL, "null")}, nil
+
+	case *object.String:
+		return &ast.StringLiteral{Token: tok(token.STRING, obj.Value), Value: obj.Value}, nil
+
+	case *object.Array:
+		elements := make([]ast.Expression, len(obj.Elements))
+		for i, el := range obj.Elements {
+			node, err := objectToNode(el, at)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = node
+		}
+		return &ast.ArrayLiteral{Token: tok(token.LBRACKET, "["), Elements: elements, Rbracket: tok(token.RBRACKET, "]")}, nil
+
+	case *object.Hash:
+		pairs := []ast.HashPair{}
+		for _, pair := range obj.Ordered() {
+			key, err := objectToNode(pair.Key, at)
+			if err != nil {
+				return nil, err
+			}
+			value, err := objectToNode(pair.Value, at)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, ast.HashPair{Key: key, Value: value})
+		}
+		return &ast.HashLiteral{Token: tok(token.LBRACE, "{"), Pairs: pairs, Rbrace: tok(token.RBRACE, "}")}, nil
+
+	case *object.Quote:
+		if exp, ok := obj.Node.(ast.Expression); ok {
+			return exp, nil
+		}
+	}
+
+	return nil, newError(object.TYPE_ERROR, "cannot unquote %s", obj.Type())
+}
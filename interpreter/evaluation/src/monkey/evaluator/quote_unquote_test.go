@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+	}
+
+	for _, tt := range tests {
+		testQuoteObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote(null))`, `null`},
+		{`quote(unquote(1.5 * 2))`, `3.0`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote([1, 2 + 3]))`, `[1, 5]`},
+		{`quote(unquote({"a": 1}))`, `{a:1}`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{`let quotedInfix = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfix))`, `(8 + (4 + 4))`},
+		// each call unquotes into a fresh copy of the quoted code
+		{`let f = fn(x) { quote(unquote(x) + 1) }; f(1); f(2)`, `(2 + 1)`},
+	}
+
+	for _, tt := range tests {
+		testQuoteObject(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(1, 2)`, "wrong number of arguments to `quote`: want=1, got=2"},
+		{`quote(unquote())`, "wrong number of arguments to `unquote`: want=1, got=0"},
+		{`quote(unquote(fn() {}))`, "cannot unquote FUNCTION"},
+		{`quote(unquote(missing))`, "identifier not found: missing"},
+		{`unquote(1)`, "`unquote` can only be called inside `quote`"},
+		{`let m = fn() { macro(x) { x } }; m()`, "macros can only be defined with a let statement at the top level"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func testQuoteObject(t *testing.T, input string, obj object.Object, expected string) bool {
+	quote, ok := obj.(*object.Quote)
+	if !ok {
+		t.Errorf("%q: expected *object.Quote. got=%T (%+v)", input, obj, obj)
+		return false
+	}
+
+	if quote.Node == nil {
+		t.Errorf("%q: quote.Node is nil", input)
+		return false
+	}
+
+	if quote.Node.String() != expected {
+		t.Errorf("%q: not equal. got=%q, want=%q", input, quote.Node.String(), expected)
+		return false
+	}
+
+	return true
+}
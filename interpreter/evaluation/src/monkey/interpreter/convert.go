@@ -0,0 +1,242 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+)
+
+// ToObject converts a Go value to an Object.
+// Booleans, integers, floats, strings, slices, arrays and maps with convertible keys are supported,
+// nil becomes NULL and Objects are returned unchanged.
+func ToObject(v interface{}) (object.Object, error) {
+	if v == nil {
+		return evaluator.NULL, nil
+	}
+	if obj, ok := v.(object.Object); ok {
+		return obj, nil
+	}
+
+	return toObject(reflect.ValueOf(v))
+}
+
+// toObject converts the Go value held in val to an Object
+func toObject(val reflect.Value) (object.Object, error) {
+	if val.IsValid() && val.CanInterface() {
+		if obj, ok := val.Interface().(object.Object); ok {
+			return obj, nil
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.Invalid:
+		return evaluator.NULL, nil
+
+	case reflect.Bool:
+		return evaluator.NativeBoolToBooleanObject(val.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: val.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val.Uint() > 1<<63-1 {
+			return nil, fmt.Errorf("cannot convert %d to INTEGER: out of range", val.Uint())
+		}
+		return &object.Integer{Value: int64(val.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: val.Float()}, nil
+
+	case reflect.String:
+		return &object.String{Value: val.String()}, nil
+
+	case reflect.Pointer, reflect.Interface:
+		if val.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return toObject(val.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return evaluator.NULL, nil
+		}
+
+		elements := make([]object.Object, val.Len())
+		for i := range elements {
+			elem, err := toObject(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = elem
+		}
+		return &object.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		if val.IsNil() {
+			return evaluator.NULL, nil
+		}
+
+		pairs := make([]object.HashPair, 0, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			key, err := toObject(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := key.(object.Hashable); !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+
+			value, err := toObject(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+
+			pairs = append(pairs, object.HashPair{Key: key, Value: value})
+		}
+
+		// Go maps are unordered, insert the keys in a stable order so the Hash prints deterministically
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.Inspect() < pairs[j].Key.Inspect() })
+
+		hash := object.NewHash()
+		for _, pair := range pairs {
+			hash.Set(pair.Key.(object.Hashable).HashKey(), pair)
+		}
+		return hash, nil
+
+	default:
+		return nil, fmt.Errorf("cannot convert %s to an object", val.Type())
+	}
+}
+
+// ToGo converts an Object to the Go value it naturally corresponds to: int64, float64, bool, string,
+// []interface{}, map[interface{}]interface{} or nil. Other Objects, such as functions, are returned unchanged.
+func ToGo(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Null:
+		return nil
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, elem := range obj.Elements {
+			elements[i] = ToGo(elem)
+		}
+		return elements
+	case *object.Hash:
+		pairs := make(map[interface{}]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			pairs[ToGo(pair.Key)] = ToGo(pair.Value)
+		}
+		return pairs
+	default:
+		return obj
+	}
+}
+
+// fromObject converts obj to a Go value of type typ
+func fromObject(obj object.Object, typ reflect.Type) (reflect.Value, error) {
+	if typ.Kind() == reflect.Interface {
+		// every Object implements the empty interface, which takes the natural Go value instead
+		if typ.NumMethod() == 0 {
+			if goVal := ToGo(obj); goVal != nil {
+				return reflect.ValueOf(goVal).Convert(typ), nil
+			}
+			return reflect.Zero(typ), nil
+		}
+		if reflect.TypeOf(obj).Implements(typ) {
+			return reflect.ValueOf(obj).Convert(typ), nil
+		}
+	}
+
+	if reflect.TypeOf(obj) == typ {
+		return reflect.ValueOf(obj), nil
+	}
+
+	switch obj := obj.(type) {
+	case *object.Integer:
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			val := reflect.New(typ).Elem()
+			if val.OverflowInt(obj.Value) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", obj.Value, typ)
+			}
+			val.SetInt(obj.Value)
+			return val, nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			val := reflect.New(typ).Elem()
+			if obj.Value < 0 || val.OverflowUint(uint64(obj.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", obj.Value, typ)
+			}
+			val.SetUint(uint64(obj.Value))
+			return val, nil
+
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(float64(obj.Value)).Convert(typ), nil
+		}
+
+	case *object.Float:
+		switch typ.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(obj.Value).Convert(typ), nil
+		}
+
+	case *object.Boolean:
+		if typ.Kind() == reflect.Bool {
+			return reflect.ValueOf(obj.Value).Convert(typ), nil
+		}
+
+	case *object.String:
+		if typ.Kind() == reflect.String {
+			return reflect.ValueOf(obj.Value).Convert(typ), nil
+		}
+
+	case *object.Null:
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			return reflect.Zero(typ), nil
+		}
+
+	case *object.Array:
+		if typ.Kind() == reflect.Slice {
+			val := reflect.MakeSlice(typ, len(obj.Elements), len(obj.Elements))
+			for i, elem := range obj.Elements {
+				converted, err := fromObject(elem, typ.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				val.Index(i).Set(converted)
+			}
+			return val, nil
+		}
+
+	case *object.Hash:
+		if typ.Kind() == reflect.Map {
+			val := reflect.MakeMapWithSize(typ, len(obj.Pairs))
+			for _, pair := range obj.Pairs {
+				key, err := fromObject(pair.Key, typ.Key())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				value, err := fromObject(pair.Value, typ.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				val.SetMapIndex(key, value)
+			}
+			return val, nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", obj.Type(), typ)
+}
@@ -0,0 +1,273 @@
+// Package interpreter embeds the Monkey evaluator in Go programs.
+//
+// An Interpreter keeps its globals between calls to Eval, and Go functions registered
+// on it can be called from scripts like any builtin:
+//
+//	interp := interpreter.New()
+//	interp.Register("greet", func(name string) string { return "Hello, " + name })
+//	result, err := interp.Eval(`greet("Monkey")`)
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
+)
+
+// Interpreter evaluates Monkey programs against its own globals and builtin table
+type Interpreter struct {
+	builtins *object.Environment // functions registered by the host, the outer scope of globals
+	globals  *object.Environment
+	macros   *object.Environment // macros defined by earlier calls to Eval
+	limits   *object.Limits      // bounds each call to Eval or Call
+}
+
+// New returns an Interpreter with empty globals and only the standard builtins.
+// Calls are limited to evaluator.DefaultMaxDepth nested function calls and steps are unbounded.
+func New() *Interpreter {
+	limits := &object.Limits{MaxDepth: evaluator.DefaultMaxDepth}
+
+	builtins := object.NewEnvironment()
+	builtins.SetLimits(limits)
+
+	return &Interpreter{
+		builtins: builtins,
+		globals:  object.NewEnclosedEnvironment(builtins),
+		macros:   object.NewEnclosedEnvironment(builtins),
+		limits:   limits,
+	}
+}
+
+// SetMaxSteps limits how many AST nodes each call to Eval or Call may evaluate, 0 means no limit
+func (i *Interpreter) SetMaxSteps(n int) {
+	i.limits.MaxSteps = n
+}
+
+// SetMaxDepth limits how deeply function calls may nest, 0 means no limit
+func (i *Interpreter) SetMaxDepth(n int) {
+	i.limits.MaxDepth = n
+}
+
+// SetCheckOverflow makes integer arithmetic overflowing int64 an error instead of wrapping around
+func (i *Interpreter) SetCheckOverflow(on bool) {
+	i.limits.CheckOverflow = on
+}
+
+// ParseError lists the syntax errors that kept a program from being evaluated
+type ParseError struct {
+	Errors []*parser.ParseError
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Eval parses and evaluates src, returning the value of its last statement. Macros it defines
+// are expanded before anything is evaluated and stay defined for later calls.
+// Runtime errors are returned as *object.Error and syntax errors as *ParseError.
+func (i *Interpreter) Eval(src string) (object.Object, error) {
+	return i.EvalFileContext(context.Background(), "", src)
+}
+
+// EvalContext is like Eval, but stops with a CanceledError once ctx is done
+func (i *Interpreter) EvalContext(ctx context.Context, src string) (object.Object, error) {
+	return i.EvalFileContext(ctx, "", src)
+}
+
+// EvalFile is like Eval, but reports positions in errors relative to filename
+func (i *Interpreter) EvalFile(filename, src string) (object.Object, error) {
+	return i.EvalFileContext(context.Background(), filename, src)
+}
+
+// EvalFileContext combines EvalFile and EvalContext
+func (i *Interpreter) EvalFileContext(ctx context.Context, filename, src string) (object.Object, error) {
+	p := parser.New(lexer.NewFile(filename, src))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.Errors()}
+	}
+
+	evaluator.DefineMacros(program, i.macros)
+
+	return i.run(ctx, func() object.Object {
+		expanded, err := evaluator.ExpandMacros(program, i.macros)
+		if err != nil {
+			return err
+		}
+		return evaluator.Eval(expanded, i.globals)
+	})
+}
+
+// Register makes the Go function fn callable from scripts as name, shadowing any builtin of the same name.
+//
+// Arguments are converted to fn's parameter types and its result back to an Object. fn may
+// return nothing, a value, an error, or a value and an error; a non-nil error is raised in
+// the script as a HostError. Functions of type object.BuiltinFunction are registered as is.
+func (i *Interpreter) Register(name string, fn interface{}) error {
+	builtin, err := wrapFunction(name, fn)
+	if err != nil {
+		return err
+	}
+
+	i.builtins.Set(name, builtin)
+	return nil
+}
+
+// Set binds name to value, converted to an Object, in the interpreter's globals
+func (i *Interpreter) Set(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+
+	i.globals.Set(name, obj)
+	return nil
+}
+
+// Get returns the value bound to name in the interpreter's globals
+func (i *Interpreter) Get(name string) (object.Object, bool) {
+	return i.globals.Get(name)
+}
+
+// Call calls the Monkey function fn with args converted to Objects
+func (i *Interpreter) Call(fn object.Object, args ...interface{}) (object.Object, error) {
+	return i.CallContext(context.Background(), fn, args...)
+}
+
+// CallContext is like Call, but stops with a CanceledError once ctx is done
+func (i *Interpreter) CallContext(ctx context.Context, fn object.Object, args ...interface{}) (object.Object, error) {
+	switch fn.(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return nil, fmt.Errorf("not a function: %s", fn.Type())
+	}
+
+	objs := make([]object.Object, len(args))
+	for idx, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, err
+		}
+		objs[idx] = obj
+	}
+
+	return i.run(ctx, func() object.Object { return evaluator.ApplyFunction(fn, objs, token.Position{}) })
+}
+
+// CallFunction calls the function bound to name in the interpreter's globals
+func (i *Interpreter) CallFunction(name string, args ...interface{}) (object.Object, error) {
+	fn, ok := i.globals.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("identifier not found: %s", name)
+	}
+
+	return i.Call(fn, args...)
+}
+
+// run evaluates with the interpreter's limits bound to ctx. Evaluations nested in a running
+// one, such as a registered Go function calling back into Monkey, share its context and step count.
+func (i *Interpreter) run(ctx context.Context, eval func() object.Object) (object.Object, error) {
+	if i.limits.Context == nil {
+		i.limits.Context, i.limits.Steps, i.limits.Depth = ctx, 0, 0
+		defer func() { i.limits.Context = nil }()
+	}
+
+	return result(eval())
+}
+
+// result splits an evaluation result into a value and a Go error
+func result(obj object.Object) (object.Object, error) {
+	if err, ok := obj.(*object.Error); ok {
+		return nil, err
+	}
+	return obj, nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// wrapFunction returns a Builtin that converts its arguments for fn and calls it
+func wrapFunction(name string, fn interface{}) (*object.Builtin, error) {
+	switch fn := fn.(type) {
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: fn}, nil
+	case func(args ...object.Object) object.Object:
+		return &object.Builtin{Fn: fn}, nil
+	}
+
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("cannot register %s: %T is not a function", name, fn)
+	}
+
+	numOut := fnType.NumOut()
+	if numOut > 2 || (numOut == 2 && fnType.Out(1) != errorType) {
+		return nil, fmt.Errorf("cannot register %s: results must be (), (T), (error) or (T, error)", name)
+	}
+
+	numIn := fnType.NumIn()
+	required := numIn
+	if fnType.IsVariadic() {
+		required--
+	}
+
+	builtin := func(args ...object.Object) object.Object {
+		if len(args) < required || (!fnType.IsVariadic() && len(args) > numIn) {
+			return evaluator.ArgumentCountError(name, required, numIn, fnType.IsVariadic(), len(args))
+		}
+
+		in := make([]reflect.Value, len(args))
+		for idx, arg := range args {
+			var paramType reflect.Type
+			if idx < required {
+				paramType = fnType.In(idx)
+			} else {
+				paramType = fnType.In(numIn - 1).Elem() // collected by the variadic parameter
+			}
+
+			val, err := fromObject(arg, paramType)
+			if err != nil {
+				return &object.Error{Kind: object.TYPE_ERROR, Message: fmt.Sprintf("argument %d to `%s`: %s", idx+1, name, err)}
+			}
+			in[idx] = val
+		}
+
+		out := fnVal.Call(in)
+
+		// a trailing error result is raised in the script
+		if len(out) > 0 && fnType.Out(len(out)-1) == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				// errors from calling back into Monkey, such as exceeded limits, pass through unchanged
+				if errObj, ok := err.(*object.Error); ok {
+					return errObj
+				}
+				return &object.Error{Kind: object.HOST_ERROR, Message: err.Error()}
+			}
+			out = out[:len(out)-1]
+		}
+
+		if len(out) == 0 {
+			return evaluator.NULL
+		}
+
+		obj, err := ToObject(out[0].Interface())
+		if err != nil {
+			return &object.Error{Kind: object.TYPE_ERROR, Message: fmt.Sprintf("result of `%s`: %s", name, err)}
+		}
+		return obj
+	}
+
+	return &object.Builtin{Fn: builtin}, nil
+}
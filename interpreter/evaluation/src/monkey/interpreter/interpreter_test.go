@@ -0,0 +1,320 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+)
+
+func TestEvalKeepsGlobals(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.Eval("let x = 5;"); err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+
+	result, err := interp.Eval("x * 2")
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+	testInteger(t, result, 10)
+
+	if _, err := New().Eval("x"); err == nil {
+		t.Errorf("expected globals not to be shared between interpreters")
+	}
+}
+
+func TestEvalExpandsMacros(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Eval(`let unless = macro(cond, cons, alt) { quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) }) };`)
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+
+	// macros stay defined for later calls to Eval
+	result, err := interp.Eval(`unless(10 > 5, 1, 2)`)
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+	testInteger(t, result, 2)
+
+	_, err = interp.Eval(`unless(true)`)
+	errObj, ok := err.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error. got=%T (%v)", err, err)
+	}
+	if errObj.Message != "wrong number of arguments to `unless`: want=3, got=1" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Eval("let = 5; let x 1;")
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError. got=%T (%v)", err, err)
+	}
+	expected := "1:5: expected next token to be IDENT. got = instead\n1:16: expected next token to be =. got INT instead"
+	if parseErr.Error() != expected {
+		t.Errorf("wrong parse errors. expected=%q, got=%q", expected, parseErr.Error())
+	}
+
+	_, err = interp.Eval("1 + true")
+	errObj, ok := err.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error. got=%T (%v)", err, err)
+	}
+	if errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	interp := New()
+
+	mustRegister(t, interp, "greet", func(name string) string { return "Hello, " + name })
+	mustRegister(t, interp, "sum", func(nums ...int) int {
+		total := 0
+		for _, n := range nums {
+			total += n
+		}
+		return total
+	})
+	mustRegister(t, interp, "double", func(nums []int64) []int64 {
+		for i := range nums {
+			nums[i] *= 2
+		}
+		return nums
+	})
+	mustRegister(t, interp, "lookup", func(m map[string]int, key string) (int, error) {
+		v, ok := m[key]
+		if !ok {
+			return 0, errors.New("no such key: " + key)
+		}
+		return v, nil
+	})
+	mustRegister(t, interp, "nothing", func() {})
+	mustRegister(t, interp, "len", func(s string) int { return 42 })
+	mustRegister(t, interp, "half", func(x float64) float64 { return x / 2 })
+	mustRegister(t, interp, "typeOf", func(v interface{}) string { return fmt.Sprintf("%T", v) })
+	mustRegister(t, interp, "types", func(vs []interface{}) string { return fmt.Sprintf("%T %T %T", vs...) })
+	mustRegister(t, interp, "field", func(m map[string]interface{}, key string) interface{} { return m[key] })
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`greet("Monkey")`, "Hello, Monkey"},
+		{"sum()", "0"},
+		{"sum(1, 2, 3)", "6"},
+		{"double([1, 2, 3])", "[2, 4, 6]"},
+		{`lookup({"a": 1}, "a")`, "1"},
+		{"nothing()", "null"},
+		{`len("abc")`, "42"},
+		{`first([1, 2])`, "1"},
+		{"half(3)", "1.5"},
+		{"half(2.5)", "1.25"},
+		{"typeOf(1)", "int64"},
+		{"typeOf(null)", "<nil>"},
+		{`typeOf([1, "a"])`, "[]interface {}"},
+		{`typeOf(fn(x) { x })`, "*object.Function"},
+		{`types([1, 2.5, "a"])`, "int64 float64 string"},
+		{`field({"a": 1}, "a") + 1`, "2"},
+	}
+
+	for _, tt := range tests {
+		result, err := interp.Eval(tt.input)
+		if err != nil {
+			t.Errorf("%q: eval error: %s", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestRegisteredFunctionErrors(t *testing.T) {
+	interp := New()
+
+	mustRegister(t, interp, "greet", func(name string) string { return "Hello, " + name })
+	mustRegister(t, interp, "small", func(n int8) int8 { return n })
+	mustRegister(t, interp, "fail", func() error { return errors.New("boom") })
+
+	tests := []struct {
+		input   string
+		kind    string
+		message string
+	}{
+		{"greet()", object.ARGUMENT_ERROR, "wrong number of arguments to `greet`: want=1, got=0"},
+		{"greet(1)", object.TYPE_ERROR, "argument 1 to `greet`: cannot convert INTEGER to string"},
+		{"small(300)", object.TYPE_ERROR, "argument 1 to `small`: 300 overflows int8"},
+		{"fail()", object.HOST_ERROR, "boom"},
+		{`try { fail() } catch (e) { throw e["kind"] }`, object.THROWN_ERROR, "HostError"},
+	}
+
+	for _, tt := range tests {
+		_, err := interp.Eval(tt.input)
+		errObj, ok := err.(*object.Error)
+		if !ok {
+			t.Errorf("%q: expected *object.Error. got=%T (%v)", tt.input, err, err)
+			continue
+		}
+		if errObj.Kind != tt.kind || errObj.Message != tt.message {
+			t.Errorf("%q: expected %s %q, got %s %q", tt.input, tt.kind, tt.message, errObj.Kind, errObj.Message)
+		}
+	}
+
+	if err := interp.Register("bad", 5); err == nil {
+		t.Errorf("expected error registering a non-function")
+	}
+	if err := interp.Register("bad", func() (int, int) { return 0, 0 }); err == nil {
+		t.Errorf("expected error registering a function with two non-error results")
+	}
+}
+
+func TestCallMonkeyFunction(t *testing.T) {
+	interp := New()
+
+	if _, err := interp.Eval("let add = fn(x, y) { x + y };"); err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+
+	result, err := interp.CallFunction("add", 2, 3)
+	if err != nil {
+		t.Fatalf("call error: %s", err)
+	}
+	testInteger(t, result, 5)
+
+	fn, _ := interp.Get("add")
+	_, err = interp.Call(fn, 1)
+	if err == nil || !strings.Contains(err.Error(), "wrong number of arguments to `add`") {
+		t.Errorf("expected arity error. got=%v", err)
+	}
+
+	// Go callbacks receive Monkey functions as Objects
+	mustRegister(t, interp, "apply", func(f object.Object, x int) (object.Object, error) {
+		return interp.Call(f, x)
+	})
+	result, err = interp.Eval("apply(fn(x) { x * 10 }, 4)")
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+	testInteger(t, result, 40)
+}
+
+func TestLimits(t *testing.T) {
+	interp := New()
+
+	_, err := interp.Eval("let f = fn() { f() }; f();")
+	if errObj, ok := err.(*object.Error); !ok || errObj.Kind != object.CALL_DEPTH_ERROR {
+		t.Errorf("expected CallDepthError by default. got=%v", err)
+	}
+
+	interp.SetMaxSteps(50)
+	countDown := "let countDown = fn(n) { if (n == 0) { 0 } else { countDown(n - 1) } };"
+	if _, err := interp.Eval(countDown); err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+
+	// the step count starts over for every evaluation
+	for i := 0; i < 3; i++ {
+		if _, err := interp.Eval("countDown(2)"); err != nil {
+			t.Fatalf("eval error: %s", err)
+		}
+	}
+
+	_, err = interp.CallFunction("countDown", 100)
+	if errObj, ok := err.(*object.Error); !ok || errObj.Kind != object.STEP_LIMIT_ERROR {
+		t.Errorf("expected StepLimitError. got=%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = interp.EvalContext(ctx, "countDown(1)")
+	if errObj, ok := err.(*object.Error); !ok || errObj.Kind != object.CANCELED_ERROR {
+		t.Errorf("expected CanceledError. got=%v", err)
+	}
+
+	// a Go callback calling back into Monkey runs under the context of the evaluation that called it
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	mustRegister(t, interp, "cancelAndCall", func(f object.Object) (object.Object, error) {
+		cancel()
+		return interp.Call(f)
+	})
+	_, err = interp.EvalContext(ctx, "try { cancelAndCall(fn() { 1 }) } catch (e) { 2 }")
+	if errObj, ok := err.(*object.Error); !ok || errObj.Kind != object.CANCELED_ERROR {
+		t.Errorf("expected CanceledError. got=%v", err)
+	}
+}
+
+// TestCheckOverflow checks the overflow setting only applies to the interpreter it is set on
+func TestCheckOverflow(t *testing.T) {
+	checked, unchecked := New(), New()
+	checked.SetCheckOverflow(true)
+
+	_, err := checked.Eval("9223372036854775807 + 1")
+	if errObj, ok := err.(*object.Error); !ok || errObj.Kind != object.ARITHMETIC_ERROR {
+		t.Errorf("expected ArithmeticError. got=%v", err)
+	}
+
+	result, err := unchecked.Eval("9223372036854775807 + 1")
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+	testInteger(t, result, math.MinInt64)
+}
+
+func TestConversions(t *testing.T) {
+	interp := New()
+
+	if err := interp.Set("config", map[string]interface{}{"name": "monkey", "sizes": []int{1, 2}, "debug": true, "parent": nil, "ratio": float32(0.5)}); err != nil {
+		t.Fatalf("set error: %s", err)
+	}
+
+	result, err := interp.Eval(`[config["name"], config["sizes"][1], config["debug"], config["parent"], config["ratio"] * 3]`)
+	if err != nil {
+		t.Fatalf("eval error: %s", err)
+	}
+
+	expected := []interface{}{"monkey", int64(2), true, nil, 1.5}
+	if got := ToGo(result); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected=%#v, got=%#v", expected, got)
+	}
+
+	if err := interp.Set("bad", 1+2i); err == nil {
+		t.Errorf("expected error converting a complex number")
+	}
+}
+
+func mustRegister(t *testing.T, interp *Interpreter, name string, fn interface{}) {
+	t.Helper()
+
+	if err := interp.Register(name, fn); err != nil {
+		t.Fatalf("register %s: %s", name, err)
+	}
+}
+
+func testInteger(t *testing.T, obj object.Object, expected int64) {
+	t.Helper()
+
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Fatalf("object is not Integer. got=%T (%+v)", obj, obj)
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+	}
+}
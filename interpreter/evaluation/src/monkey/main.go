@@ -1,14 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
 
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/repl"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/format"
 )
 
+var engine = flag.String("engine", repl.ENGINE_EVAL, "execution engine to use: 'eval' or 'vm'")
+var checkOverflow = flag.Bool("check-overflow", false, "report integer overflow as an error instead of wrapping around")
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(formatFiles(os.Args[2:]))
+	}
+
+	flag.Parse()
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -18,5 +31,74 @@ func main() {
 	fmt.Println("Statements need a semicolon to end; enter `exit()` or CTRL-d (i.e. EOF) to exit. Synatx: https://monkeylang.org")
 	fmt.Printf("\n")
 
-	repl.REPL(os.Stdin, os.Stdout)
+	repl.REPL(os.Stdin, os.Stdout, *engine, *checkOverflow)
+}
+
+// formatFiles runs `monkey fmt [-w] [-d] [files]`, which prints the given files, or standard input
+// when there are none, in the canonical style. It returns the exit status.
+func formatFiles(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the file instead of standard output")
+	diff := flags.Bool("d", false, "show a diff of the changes instead of the formatted source")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey fmt [-w] [-d] [files]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "monkey fmt: cannot use -w with standard input")
+			return 2
+		}
+
+		src, err := io.ReadAll(os.Stdin)
+		if err == nil {
+			err = formatFile("<standard input>", src, false, *diff)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err == nil {
+			err = formatFile(path, src, *write, *diff)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+		}
+	}
+
+	return status
+}
+
+// formatFile formats the source read from path, rewriting the file if write is set and printing
+// the changes if diff is. Without either, the formatted source is printed.
+func formatFile(path string, src []byte, write, diff bool) error {
+	formatted, err := format.Source(path, src)
+	if err != nil {
+		return err
+	}
+
+	if diff {
+		os.Stdout.Write(format.Diff(path+".orig", src, path, formatted))
+	}
+	if write && !bytes.Equal(src, formatted) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, formatted, info.Mode().Perm())
+	}
+	if !write && !diff {
+		os.Stdout.Write(formatted)
+	}
+
+	return nil
 }
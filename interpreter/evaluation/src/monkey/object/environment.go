@@ -1,5 +1,7 @@
 package object
 
+import "context"
+
 // NewEnvironment creates a new environment
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
@@ -15,8 +17,9 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 
 // Environment holds variable bindings in the current and outer scopes
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store  map[string]Object
+	outer  *Environment
+	limits *Limits
 }
 
 // Get returns the object bindings from current or outer scope
@@ -33,3 +36,43 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// Assign updates an existing binding in the closest scope that defines it.
+// It returns false if name is not bound in e or any outer scope.
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return true
+		}
+	}
+	return false
+}
+
+// Limits bounds the work done evaluating code in an environment and the environments it encloses.
+// A zero MaxSteps or MaxDepth leaves that resource unbounded.
+type Limits struct {
+	Context  context.Context // evaluation stops once it is done, may be nil
+	MaxSteps int             // maximum number of AST nodes evaluated
+	MaxDepth int             // maximum number of nested function calls
+
+	CheckOverflow bool // integer arithmetic overflowing int64 is an error instead of wrapping around
+
+	Steps int // nodes evaluated so far
+	Depth int // function calls currently active
+}
+
+// SetLimits bounds evaluation in e and every environment enclosed by it
+func (e *Environment) SetLimits(l *Limits) {
+	e.limits = l
+}
+
+// Limits returns the limits set on e or its closest outer environment, nil if there are none
+func (e *Environment) Limits() *Limits {
+	for env := e; env != nil; env = env.outer {
+		if env.limits != nil {
+			return env.limits
+		}
+	}
+	return nil
+}
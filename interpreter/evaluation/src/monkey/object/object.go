@@ -4,25 +4,61 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/code"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
 )
 
 type ObjectType string
 type BuiltinFunction func(args ...Object) Object
 
+// CallFunction calls a function value with already evaluated arguments in the engine running a builtin
+type CallFunction func(fn Object, args ...Object) Object
+
+// HigherOrderFunction is a builtin that calls back into the functions it is given, using call
+type HigherOrderFunction func(call CallFunction, args ...Object) Object
+
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	QUOTE_OBJ        = "QUOTE"
+	MACRO_OBJ        = "MACRO"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CLOSURE_OBJ           = "CLOSURE"
+)
+
+// Kinds of Error, scripts can inspect them when catching errors
+const (
+	THROWN_ERROR     = "Error" // raised by a throw statement
+	TYPE_ERROR       = "TypeError"
+	NAME_ERROR       = "NameError"
+	INDEX_ERROR      = "IndexError"
+	ARGUMENT_ERROR   = "ArgumentError"
+	ARITHMETIC_ERROR = "ArithmeticError"
+	HOST_ERROR       = "HostError"  // returned by a Go function the embedding program registered
+	MACRO_ERROR      = "MacroError" // raised by misplaced macros, unquote, or expanding a macro call
+
+	// Raised when evaluation exceeds its Limits, these errors can't be caught
+	CANCELED_ERROR   = "CanceledError"
+	STEP_LIMIT_ERROR = "StepLimitError"
+	CALL_DEPTH_ERROR = "CallDepthError"
 )
 
 // Object represents interpreted values
@@ -44,6 +80,21 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
+// Float Object represents a 64-bit floating point number
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	// Whole numbers keep a ".0" so they can't be mistaken for integers
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+	return s + ".0"
+}
+
 // Boolean Object represents a Boolean
 type Boolean struct {
 	Value bool
@@ -66,17 +117,84 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return fmt.Sprintf("%v", rv.Value.Inspect()) }
 
-// Error contains the error message
+// Break signals that the innermost loop should end
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+// Continue signals that the innermost loop should move on to its next iteration
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+// Error contains the error message, where it occurred and the function calls that led to it
 type Error struct {
 	Message string
+	Kind    string         // one of the error kinds, e.g. TYPE_ERROR
+	Pos     token.Position // where the error occurred
+	Trace   []TraceFrame   // calls that were active when the error occurred, innermost first
+	Fatal   bool           // aborts evaluation, try/catch doesn't handle it
+}
+
+// TraceFrame is a function call that an Error unwound through
+type TraceFrame struct {
+	Function string         // name of the called function
+	CallSite token.Position // where the function was called from
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return fmt.Sprintf("ERROR: %s", e.Message) }
 
+// Error lets an Error object be returned wherever Go expects an error
+func (e *Error) Error() string { return e.Message }
+
+// Traceback returns the error message followed by the position reached in each active function, innermost first
+func (e *Error) Traceback() string {
+	var out bytes.Buffer
+
+	out.WriteString(e.Inspect())
+
+	// runs of identical frames, as left by deep recursion, are collapsed into one line
+	pos := e.Pos
+	last, repeated := "", 0
+	for _, frame := range e.Trace {
+		line := fmt.Sprintf("\n    at %s (%s)", frame.Function, pos)
+		pos = frame.CallSite
+
+		if line == last {
+			repeated++
+			continue
+		}
+		writeRepeated(&out, repeated)
+
+		out.WriteString(line)
+		last, repeated = line, 0
+	}
+	writeRepeated(&out, repeated)
+
+	if pos.IsValid() {
+		out.WriteString(fmt.Sprintf("\n    at <program> (%s)", pos))
+	}
+
+	return out.String()
+}
+
+// writeRepeated notes how many times the previous traceback line was repeated
+func writeRepeated(out *bytes.Buffer, repeated int) {
+	if repeated > 0 {
+		out.WriteString(fmt.Sprintf("\n    ... repeated %d more times", repeated))
+	}
+}
+
 // Function is an representation of functions
 type Function struct {
+	Name       string // the name the function literal was let-bound to, empty if anonymous
+	Doc        string // the comments written above the let binding, shown by help
 	Parameters []*ast.Identifier
+	Defaults   map[string]ast.Expression // default values of optional parameters
+	Rest       *ast.Identifier           // collects surplus arguments into an array, nil if not variadic
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -85,14 +203,9 @@ func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
 	out.WriteString("fn")
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ast.ParameterList(f.Parameters, f.Defaults, f.Rest))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
 	out.WriteString("\n}")
@@ -100,6 +213,36 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// Quote is an unevaluated piece of code, produced by quote and returned by macros
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (q *Quote) Inspect() string  { return "QUOTE(" + q.Node.String() + ")" }
+
+// Macro is a representation of macros, which take and return code instead of values
+type Macro struct {
+	Name       string // the name the macro literal was let-bound to
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() ObjectType { return MACRO_OBJ }
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(ast.ParameterList(m.Parameters, nil, nil))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
 // String is a representation of string
 type String struct {
 	Value string
@@ -110,7 +253,8 @@ func (s *String) Inspect() string  { return s.Value }
 
 // Builtin represents builtin functions
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn          BuiltinFunction
+	HigherOrder HigherOrderFunction // used instead of Fn when set
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -161,6 +305,15 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// HashKey return a HashKey object based on the Float value.
+// Whole numbers share the key of the equal Integer, so 1.0 and 1 find the same hash entry.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
 // HashKey return a HashKey object based on the String value
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
@@ -173,8 +326,33 @@ type HashPair struct {
 	Value Object // Objects that the Key maps to
 }
 
+// Hash maps keys to values and remembers the order keys were first inserted in.
+// Pairs is used for lookups, new pairs must be added with Set so the order stays in sync.
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	Order []HashKey // the keys of Pairs in insertion order
+}
+
+// NewHash returns an empty Hash
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set stores pair under key. A new key goes after all the others, an existing key keeps its place.
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if _, ok := h.Pairs[key]; !ok {
+		h.Order = append(h.Order, key)
+	}
+	h.Pairs[key] = pair
+}
+
+// Ordered returns the pairs of the hash in insertion order
+func (h *Hash) Ordered() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Order))
+	for _, key := range h.Order {
+		pairs = append(pairs, h.Pairs[key])
+	}
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
@@ -182,7 +360,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.Ordered() {
 		key := pair.Key.Inspect()
 		value := pair.Value.Inspect()
 		pairs = append(pairs, key+":"+value)
@@ -194,3 +372,48 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// CompiledFunction holds the bytecode of a function literal produced by the compiler
+type CompiledFunction struct {
+	Name          string // the name the function literal was let-bound to, empty if anonymous
+	Doc           string // the comments written above the let binding, shown by help
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int      // number of positional parameters
+	NumDefaults   int      // how many of the trailing positional parameters have a default value
+	Variadic      bool     // surplus arguments are collected into an array in the local after the parameters
+	LocalNames    []string // the name each local slot is bound to, for the errors of reading one unset
+	FreeNames     []string // the name of each free variable
+	Positions     []SourcePosition
+}
+
+// SourcePosition attributes the instructions from Offset up to the next SourcePosition to the code at Pos
+type SourcePosition struct {
+	Offset int
+	Pos    token.Position
+}
+
+// PositionAt returns where the code the instruction containing offset ip was compiled from is
+func (cf *CompiledFunction) PositionAt(ip int) token.Position {
+	i := sort.Search(len(cf.Positions), func(i int) bool { return cf.Positions[i].Offset > ip })
+	if i == 0 {
+		return token.Position{}
+	}
+	return cf.Positions[i-1].Pos
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure pairs a CompiledFunction with the free variables it captured
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Type() ObjectType { return CLOSURE_OBJ }
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
@@ -20,3 +20,21 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := NewHash()
+	for _, pair := range []struct {
+		key   string
+		value int64
+	}{{"b", 1}, {"a", 2}, {"c", 3}, {"b", 4}} {
+		key := &String{Value: pair.key}
+		hash.Set(key.HashKey(), HashPair{Key: key, Value: &Integer{Value: pair.value}})
+	}
+
+	if got, want := hash.Inspect(), "{b:4, a:2, c:3}"; got != want {
+		t.Errorf("hash.Inspect() wrong. got=%q, want=%q", got, want)
+	}
+	if len(hash.Pairs) != 3 || len(hash.Order) != 3 {
+		t.Errorf("hash has wrong size. got %d pairs and %d ordered keys", len(hash.Pairs), len(hash.Order))
+	}
+}
@@ -7,14 +7,23 @@ import (
 	"os"
 	"strings"
 
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/compiler"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/vm"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
 )
 
 const PROMPT = ">> "
 
+// Engines the REPL can execute programs with
+const (
+	ENGINE_EVAL = "eval" // tree-walking evaluator
+	ENGINE_VM   = "vm"   // bytecode compiler and VM
+)
+
 const MONKEY_FACE = `            __,__
    .--.  .-"     "-.  .--.
   / .. \/  .-. .-.  \/ .. \
@@ -28,9 +37,17 @@ const MONKEY_FACE = `            __,__
            '-----'
 `
 
-func REPL(in io.Reader, out io.Writer) {
+func REPL(in io.Reader, out io.Writer, engine string, checkOverflow bool) {
 	reader := bufio.NewReader(in)
 	env := object.NewEnvironment()
+	env.SetLimits(&object.Limits{MaxDepth: evaluator.DefaultMaxDepth, CheckOverflow: checkOverflow})
+	macroEnv := object.NewEnvironment()
+	macroEnv.SetLimits(env.Limits())
+
+	// State kept between lines when running on the VM
+	constants := []object.Object{}
+	globals := make([]object.Object, vm.GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
 
 	for {
 		fmt.Printf(PROMPT)
@@ -61,14 +78,54 @@ func REPL(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
+		// Macros are expanded for both engines, before anything runs
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, macroErr := evaluator.ExpandMacros(program, macroEnv)
+
+		var evaluated object.Object
+		switch {
+		case macroErr != nil:
+			evaluated = macroErr
+		case engine == ENGINE_VM:
+			evaluated = runVM(out, expanded.(*ast.Program), symbolTable, &constants, globals, env.Limits())
+		default:
+			evaluated = evaluator.Eval(expanded, env)
+		}
+
+		if errObj, ok := evaluated.(*object.Error); ok {
+			io.WriteString(out, errObj.Traceback())
+			io.WriteString(out, "\n")
+		} else if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
 }
 
+// runVM compiles and runs program on the VM within limits, continuing from the state of previous lines
+func runVM(out io.Writer, program *ast.Program, symbolTable *compiler.SymbolTable, constants *[]object.Object, globals []object.Object, limits *object.Limits) object.Object {
+	comp := compiler.NewWithState(symbolTable, *constants)
+	if err := comp.Compile(program); err != nil {
+		io.WriteString(out, "Woops! Compilation failed:\n "+err.Error()+"\n")
+		return nil
+	}
+
+	bytecode := comp.Bytecode()
+	*constants = bytecode.Constants
+
+	machine := vm.NewWithGlobalsStore(bytecode, globals)
+	machine.SetLimits(limits)
+	if err := machine.Run(); err != nil {
+		if errObj, ok := err.(*object.Error); ok {
+			return errObj
+		}
+		io.WriteString(out, "Woops! Executing bytecode failed:\n "+err.Error()+"\n")
+		return nil
+	}
+
+	return machine.LastPoppedStackElem()
+}
+
 func check(err error) {
 	if err != nil {
 		fmt.Println(err)
@@ -100,11 +157,11 @@ func acceptUntil(r *bufio.Reader, start, end string) (string, error) {
 	return buf.String(), nil
 }
 
-func printParserErrors(out io.Writer, errors []string) {
+func printParserErrors(out io.Writer, errors []*parser.ParseError) {
 	io.WriteString(out, MONKEY_FACE)
 	io.WriteString(out, "Woops! We ran into some monkey business here!\n")
 	io.WriteString(out, " parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+	for _, err := range errors {
+		io.WriteString(out, "\t"+err.Error()+"\n")
 	}
 }
@@ -0,0 +1,24 @@
+package vm
+
+import (
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/code"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+)
+
+// Frame is the call frame of a closure being executed
+type Frame struct {
+	cl          *object.Closure
+	ip          int // instruction pointer within cl's instructions
+	basePointer int // stack pointer before the call, locals live above it
+	numArgs     int // number of arguments the closure was called with
+}
+
+// NewFrame returns a Frame for cl whose locals start at basePointer
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+// Instructions returns the bytecode executed by this frame
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
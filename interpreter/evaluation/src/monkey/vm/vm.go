@@ -0,0 +1,779 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/code"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/compiler"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+)
+
+const StackSize = 2048       // initial size of the stack, it grows as calls need more
+const MaxStackSize = 1 << 22 // size the stack can't grow past
+const GlobalsSize = 65536
+
+// infixOperators maps infix opcodes back to the operator the evaluator applies
+var infixOperators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpPow:          "**",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpGreaterThan:  ">",
+	code.OpLessThan:     "<",
+	code.OpGreaterEqual: ">=",
+	code.OpLessEqual:    "<=",
+}
+
+// prefixOperators maps prefix opcodes back to the operator the evaluator applies
+var prefixOperators = map[code.Opcode]string{
+	code.OpMinus: "-",
+	code.OpBang:  "!",
+}
+
+// VM is a stack machine that executes the Bytecode produced by the compiler.
+// Operators, indexing and builtins are delegated to the evaluator so both engines share semantics.
+type VM struct {
+	constants []object.Object
+
+	stack []object.Object
+	sp    int // always points to the next free slot, top of stack is stack[sp-1]
+
+	globals     []object.Object
+	globalNames []string
+
+	frames      []*Frame
+	framesIndex int
+
+	handlers []handler // handlers of the try expressions being executed, innermost last
+
+	lastPopped object.Object
+
+	limits *object.Limits
+}
+
+// New returns a VM ready to run the given bytecode with fresh globals
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Positions: bytecode.Positions}
+	mainClosure := &object.Closure{Fn: mainFn}
+	mainFrame := NewFrame(mainClosure, 0)
+
+	frames := []*Frame{mainFrame}
+
+	return &VM{
+		constants:   bytecode.Constants,
+		stack:       make([]object.Object, StackSize),
+		sp:          0,
+		globals:     make([]object.Object, GlobalsSize),
+		globalNames: bytecode.GlobalNames,
+		frames:      frames,
+		framesIndex: 1,
+	}
+}
+
+// NewWithGlobalsStore returns a VM that reads and writes the given globals.
+// The REPL uses this to keep bindings between lines.
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object) *VM {
+	vm := New(bytecode)
+	vm.globals = s
+	return vm
+}
+
+// SetLimits bounds the execution of the VM. Every instruction executed counts as a step,
+// and call depth is capped by l.MaxDepth, or evaluator.DefaultMaxDepth if it is not set.
+func (vm *VM) SetLimits(l *object.Limits) {
+	vm.limits = l
+}
+
+// LastPoppedStackElem returns the value of the last expression statement executed
+func (vm *VM) LastPoppedStackElem() object.Object {
+	return vm.lastPopped
+}
+
+// Run executes the bytecode until the main frame finishes.
+// Monkey runtime errors no try expression handles are returned as *object.Error.
+func (vm *VM) Run() error {
+	return vm.run(0)
+}
+
+// run executes instructions until the frame at index stopAt returns, or the main frame finishes.
+// Errors raised in those frames resume execution at the handler of the try expression they were raised in.
+func (vm *VM) run(stopAt int) error {
+	for {
+		err := vm.execute(stopAt)
+		if err == nil {
+			return nil
+		}
+		vm.locate(err)
+		if !vm.handle(err, stopAt) {
+			return err
+		}
+	}
+}
+
+// locate records where a newly raised error occurred and the calls active at that point,
+// which the evaluator collects as the error unwinds through them
+func (vm *VM) locate(err error) {
+	errObj, ok := err.(*object.Error)
+	if !ok || errObj.Pos.IsValid() {
+		return
+	}
+
+	frame := vm.currentFrame()
+	errObj.Pos = frame.cl.Fn.PositionAt(frame.ip)
+
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		caller := vm.frames[i-1]
+		errObj.Trace = append(errObj.Trace, object.TraceFrame{
+			Function: functionName(vm.frames[i].cl.Fn),
+			CallSite: caller.cl.Fn.PositionAt(caller.ip),
+		})
+	}
+}
+
+// execute runs instructions until the frame at index stopAt returns, the main frame finishes or an error is raised
+func (vm *VM) execute(stopAt int) error {
+	var ip int
+	var ins code.Instructions
+	var op code.Opcode
+
+	for vm.framesIndex > stopAt && vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		vm.currentFrame().ip++
+
+		ip = vm.currentFrame().ip
+		ins = vm.currentFrame().Instructions()
+		op = code.Opcode(ins[ip])
+
+		if err := evaluator.TakeStep(vm.limits); err != nil {
+			return err
+		}
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			if err := vm.push(vm.constants[constIndex]); err != nil {
+				return err
+			}
+
+		case code.OpPop:
+			vm.lastPopped = vm.pop()
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan,
+			code.OpGreaterEqual, code.OpLessEqual:
+			right := vm.pop()
+			left := vm.pop()
+
+			if err := vm.pushResult(evaluator.EvalInfix(infixOperators[op], left, right, vm.limits)); err != nil {
+				return err
+			}
+
+		case code.OpMinus, code.OpBang:
+			right := vm.pop()
+
+			if err := vm.pushResult(evaluator.EvalPrefix(prefixOperators[op], right, vm.limits)); err != nil {
+				return err
+			}
+
+		case code.OpTrue:
+			if err := vm.push(evaluator.TRUE); err != nil {
+				return err
+			}
+
+		case code.OpFalse:
+			if err := vm.push(evaluator.FALSE); err != nil {
+				return err
+			}
+
+		case code.OpNull:
+			if err := vm.push(evaluator.NULL); err != nil {
+				return err
+			}
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip = pos - 1
+
+		case code.OpJumpIfArgument:
+			argIndex := int(code.ReadUint8(ins[ip+1:]))
+			pos := int(code.ReadUint16(ins[ip+2:]))
+			vm.currentFrame().ip += 3
+
+			if argIndex < vm.currentFrame().numArgs {
+				vm.currentFrame().ip = pos - 1
+			}
+
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			condition := vm.pop()
+			if !evaluator.IsTruthy(condition) {
+				vm.currentFrame().ip = pos - 1
+			}
+
+		case code.OpJumpNull:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			if vm.stack[vm.sp-1] == evaluator.NULL {
+				vm.currentFrame().ip = pos - 1
+			}
+
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			vm.globals[globalIndex] = vm.pop()
+
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			if err := vm.pushVariable(vm.globals[globalIndex], vm.globalNames[globalIndex]); err != nil {
+				return err
+			}
+
+		case code.OpSetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			slot := frame.basePointer + int(localIndex)
+			if c, ok := vm.stack[slot].(*cell); ok {
+				c.value = vm.pop()
+			} else {
+				vm.stack[slot] = vm.pop()
+			}
+
+		case code.OpGetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			if err := vm.pushVariable(vm.stack[frame.basePointer+int(localIndex)], frame.cl.Fn.LocalNames[localIndex]); err != nil {
+				return err
+			}
+
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			cl := vm.currentFrame().cl
+			if err := vm.pushVariable(cl.Free[freeIndex], cl.Fn.FreeNames[freeIndex]); err != nil {
+				return err
+			}
+
+		case code.OpSetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			vm.currentFrame().cl.Free[freeIndex].(*cell).value = vm.pop()
+
+		case code.OpCaptureLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			frame := vm.currentFrame()
+			slot := frame.basePointer + int(localIndex)
+			c, ok := vm.stack[slot].(*cell)
+			if !ok {
+				c = &cell{value: vm.stack[slot]}
+				vm.stack[slot] = c
+			}
+
+			if err := vm.push(c); err != nil {
+				return err
+			}
+
+		case code.OpCaptureFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			if err := vm.push(vm.currentFrame().cl.Free[freeIndex]); err != nil {
+				return err
+			}
+
+		case code.OpCurrentClosure:
+			if err := vm.push(vm.currentFrame().cl); err != nil {
+				return err
+			}
+
+		case code.OpGetBuiltin:
+			builtinIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			if err := vm.push(evaluator.Builtins[builtinIndex]); err != nil {
+				return err
+			}
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			array := vm.buildArray(vm.sp-numElements, vm.sp)
+			vm.sp = vm.sp - numElements
+
+			if err := vm.push(array); err != nil {
+				return err
+			}
+
+		case code.OpTemplate:
+			numParts := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			str := evaluator.Interpolate(vm.stack[vm.sp-numParts : vm.sp])
+			vm.sp = vm.sp - numParts
+
+			if err := vm.push(str); err != nil {
+				return err
+			}
+
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			hash := vm.buildHash(vm.sp-numElements, vm.sp)
+			vm.sp = vm.sp - numElements
+
+			if err := vm.pushResult(hash); err != nil {
+				return err
+			}
+
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			if err := vm.pushResult(evaluator.EvalIndex(left, index)); err != nil {
+				return err
+			}
+
+		case code.OpSlice:
+			step := vm.pop()
+			stop := vm.pop()
+			start := vm.pop()
+			left := vm.pop()
+
+			if err := vm.pushResult(evaluator.EvalSlice(left, start, stop, step)); err != nil {
+				return err
+			}
+
+		case code.OpSetIndex:
+			val := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			if err := vm.pushResult(evaluator.EvalIndexAssign(left, index, val)); err != nil {
+				return err
+			}
+
+		case code.OpDup2:
+			if err := vm.push(vm.stack[vm.sp-2]); err != nil {
+				return err
+			}
+			if err := vm.push(vm.stack[vm.sp-2]); err != nil {
+				return err
+			}
+
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			numFree := code.ReadUint8(ins[ip+3:])
+			vm.currentFrame().ip += 3
+
+			if err := vm.pushClosure(int(constIndex), int(numFree)); err != nil {
+				return err
+			}
+
+		case code.OpIterator:
+			values, err := evaluator.IterationValues(vm.pop())
+			if err != nil {
+				return err
+			}
+
+			if err := vm.push(&iterator{values: values}); err != nil {
+				return err
+			}
+
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			it := vm.pop().(*iterator)
+			if it.next >= len(it.values) {
+				vm.currentFrame().ip = pos - 1
+				continue
+			}
+
+			value := it.values[it.next]
+			it.next++
+			if err := vm.push(value); err != nil {
+				return err
+			}
+
+		case code.OpSetupCatch, code.OpSetupFinally:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			vm.handlers = append(vm.handlers, handler{
+				target:      pos,
+				framesIndex: vm.framesIndex,
+				sp:          vm.sp,
+				catch:       op == code.OpSetupCatch,
+			})
+
+		case code.OpPopHandler:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case code.OpThrow:
+			val := vm.pop()
+
+			// the error a finally handler pushed is raised again once the finally block has run
+			if errObj, ok := val.(*object.Error); ok {
+				return errObj
+			}
+			return evaluator.ThrownError(val)
+
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			if err := vm.executeCall(int(numArgs)); err != nil {
+				return err
+			}
+
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			// A return statement at the top level ends the program
+			if vm.framesIndex == 1 {
+				vm.lastPopped = returnValue
+				return nil
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			if err := vm.push(returnValue); err != nil {
+				return err
+			}
+
+		case code.OpReturn:
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			if err := vm.push(evaluator.NULL); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown opcode %d", op)
+		}
+	}
+
+	return nil
+}
+
+// handle resumes execution at the innermost handler installed by the frames above stopAt,
+// reporting whether there was one for err. Fatal errors and failures of the VM itself are never handled.
+func (vm *VM) handle(err error, stopAt int) bool {
+	errObj, ok := err.(*object.Error)
+	if !ok || errObj.Fatal || len(vm.handlers) == 0 {
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	if h.framesIndex <= stopAt {
+		return false
+	}
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	// unwind the calls made since the handler was installed
+	vm.framesIndex = h.framesIndex
+	vm.sp = h.sp
+	vm.currentFrame().ip = h.target - 1
+
+	var caught object.Object = errObj
+	if h.catch {
+		caught = evaluator.ErrorToHash(errObj)
+	}
+	return vm.push(caught) == nil
+}
+
+// currentFrame returns the frame being executed
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+// pushFrame makes f the frame being executed
+func (vm *VM) pushFrame(f *Frame) error {
+	maxDepth := evaluator.DefaultMaxDepth
+	if vm.limits != nil && vm.limits.MaxDepth > 0 {
+		maxDepth = vm.limits.MaxDepth
+	}
+	// the main frame doesn't count as a call
+	if vm.framesIndex > maxDepth {
+		return evaluator.CallDepthError(maxDepth)
+	}
+
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+	return nil
+}
+
+// popFrame returns to the caller's frame
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// push puts o on top of the stack
+func (vm *VM) push(o object.Object) error {
+	if err := vm.growStack(vm.sp + 1); err != nil {
+		return err
+	}
+
+	vm.stack[vm.sp] = o
+	vm.sp++
+
+	return nil
+}
+
+// growStack makes room for at least size slots on the stack
+func (vm *VM) growStack(size int) error {
+	if size <= len(vm.stack) {
+		return nil
+	}
+	if size > MaxStackSize {
+		return fmt.Errorf("stack overflow")
+	}
+
+	grown := make([]object.Object, min(max(size, 2*len(vm.stack)), MaxStackSize))
+	copy(grown, vm.stack)
+	vm.stack = grown
+	return nil
+}
+
+// pushVariable pushes the value of the variable called name, which is nil if its let statement hasn't run yet,
+// like one in a branch that wasn't taken. Variables captured by closures hold their value in a cell.
+func (vm *VM) pushVariable(o object.Object, name string) error {
+	if c, ok := o.(*cell); ok {
+		o = c.value
+	}
+	if o == nil {
+		return &object.Error{Kind: object.NAME_ERROR, Message: "identifier not found: " + name}
+	}
+	return vm.push(o)
+}
+
+// pushResult pushes the result of an operation, stopping execution if it is an error
+func (vm *VM) pushResult(o object.Object) error {
+	if errObj, ok := o.(*object.Error); ok {
+		return errObj
+	}
+	return vm.push(o)
+}
+
+// pop removes and returns the top of the stack
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+// buildArray returns an Array of the stack elements between startIndex and endIndex
+func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
+	elements := make([]object.Object, endIndex-startIndex)
+
+	for i := startIndex; i < endIndex; i++ {
+		elements[i-startIndex] = vm.stack[i]
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// buildHash returns a Hash of the key value pairs between startIndex and endIndex
+func (vm *VM) buildHash(startIndex, endIndex int) object.Object {
+	hash := object.NewHash()
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return &object.Error{Kind: object.TYPE_ERROR, Message: fmt.Sprintf("unusable as hash key: %s", key.Type())}
+		}
+
+		hash.Set(hashKey.HashKey(), object.HashPair{Key: key, Value: value})
+	}
+
+	return hash
+}
+
+// pushClosure wraps the compiled function at constIndex with numFree captured values from the stack
+func (vm *VM) pushClosure(constIndex int, numFree int) error {
+	constant := vm.constants[constIndex]
+	function, ok := constant.(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", constant)
+	}
+
+	free := make([]object.Object, numFree)
+	for i := 0; i < numFree; i++ {
+		free[i] = vm.stack[vm.sp-numFree+i]
+	}
+	vm.sp = vm.sp - numFree
+
+	closure := &object.Closure{Fn: function, Free: free}
+	return vm.push(closure)
+}
+
+// executeCall calls the closure or builtin sitting below its numArgs arguments on the stack
+func (vm *VM) executeCall(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.Builtin:
+		return vm.callBuiltin(callee, numArgs)
+	default:
+		return &object.Error{Kind: object.TYPE_ERROR, Message: fmt.Sprintf("not a function: %s", callee.Type())}
+	}
+}
+
+// functionName returns the name fn is shown as in errors and tracebacks
+func functionName(fn *object.CompiledFunction) string {
+	if fn.Name == "" {
+		return "<anonymous>"
+	}
+	return fn.Name
+}
+
+// callClosure pushes a new frame for cl, its arguments become its first locals
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	fn := cl.Fn
+
+	required := fn.NumParameters - fn.NumDefaults
+	if numArgs < required || (!fn.Variadic && numArgs > fn.NumParameters) {
+		return evaluator.ArgumentCountError(functionName(fn), required, fn.NumParameters, fn.Variadic, numArgs)
+	}
+
+	frame := NewFrame(cl, vm.sp-numArgs)
+	frame.numArgs = numArgs
+	if err := vm.growStack(frame.basePointer + fn.NumLocals); err != nil {
+		return err
+	}
+
+	// collect surplus arguments into the rest parameter
+	var rest []object.Object
+	if fn.Variadic && numArgs > fn.NumParameters {
+		rest = append(rest, vm.stack[frame.basePointer+fn.NumParameters:vm.sp]...)
+	}
+
+	// clear the locals after the arguments of whatever the stack held there before
+	for i := frame.basePointer + min(numArgs, fn.NumParameters); i < frame.basePointer+fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+
+	if fn.Variadic {
+		if rest == nil {
+			rest = []object.Object{}
+		}
+		vm.stack[frame.basePointer+fn.NumParameters] = &object.Array{Elements: rest}
+	}
+
+	if err := vm.pushFrame(frame); err != nil {
+		return err
+	}
+
+	vm.sp = frame.basePointer + fn.NumLocals
+
+	return nil
+}
+
+// callBuiltin runs builtin with the arguments on the stack and replaces the call with its result
+func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
+	args := vm.stack[vm.sp-numArgs : vm.sp]
+
+	var result object.Object
+	if builtin.HigherOrder != nil {
+		result = builtin.HigherOrder(vm.callFunction, args...)
+	} else {
+		result = builtin.Fn(args...)
+	}
+	vm.sp = vm.sp - numArgs - 1
+
+	if result == nil {
+		return vm.push(evaluator.NULL)
+	}
+	return vm.pushResult(result)
+}
+
+// callFunction calls fn on behalf of a builtin and runs it to completion, returning its result.
+// The call is made above the builtin's arguments on the stack, so they stay intact.
+func (vm *VM) callFunction(fn object.Object, args ...object.Object) object.Object {
+	if err := vm.push(fn); err != nil {
+		return asError(err)
+	}
+	for _, arg := range args {
+		if err := vm.push(arg); err != nil {
+			return asError(err)
+		}
+	}
+
+	depth := vm.framesIndex
+	if err := vm.executeCall(len(args)); err != nil {
+		return asError(err)
+	}
+	// closures push a frame that runs until it returns, builtins have already pushed their result
+	if vm.framesIndex > depth {
+		if err := vm.run(depth); err != nil {
+			return asError(err)
+		}
+	}
+
+	return vm.pop()
+}
+
+// asError returns err as an *object.Error so it can be handed back to a builtin
+func asError(err error) *object.Error {
+	if errObj, ok := err.(*object.Error); ok {
+		return errObj
+	}
+	return &object.Error{Message: err.Error()}
+}
+
+// handler is where execution resumes when an error is raised inside a try expression
+type handler struct {
+	target      int  // position of the catch or finally code in the frame's instructions
+	framesIndex int  // framesIndex of the frame executing the try expression
+	sp          int  // stack pointer when the handler was installed
+	catch       bool // whether the catch code gets the caught error's hash, finally code gets the error itself
+}
+
+// cell holds a variable shared between the scope defining it and the closures capturing it
+type cell struct {
+	value object.Object
+}
+
+func (c *cell) Type() object.ObjectType { return "CELL" }
+func (c *cell) Inspect() string         { return "cell" }
+
+// iterator walks the values a for loop visits, it is kept in a hidden variable while the loop runs
+type iterator struct {
+	values []object.Object
+	next   int // index of the value to visit next
+}
+
+func (it *iterator) Type() object.ObjectType { return "ITERATOR" }
+func (it *iterator) Inspect() string         { return "iterator" }
@@ -0,0 +1,610 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/compiler"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/evaluator"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/evaluation/src/monkey/object"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
+)
+
+type vmTestCase struct {
+	input    string
+	expected interface{}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1", 1},
+		{"1 + 2", 3},
+		{"50 / 2 * 2 + 10 - 5", 55},
+		{"5 * (2 + 10)", 60},
+		{"-50 + 100 + -50", 0},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"2 + 10 % 4 * 3", 8},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1.5", 1.5},
+		{"-0.5 + 1", 0.5},
+		{"7 / 2.0", 3.5},
+		{"2 ** -2", 0.25},
+		{"let avg = fn(a, b) { (a + b) / 2.0 }; avg(3, 4)", 3.5},
+		{"round(2.675, 1)", 2.7},
+		{"floor(-1.5)", -2},
+		{"1.5 < 2", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTemplateStrings(t *testing.T) {
+	tests := []vmTestCase{
+		{`let name = "Monkey"; "Hello ${name}!"`, "Hello Monkey!"},
+		{`let age = 4; "${age + 1} years, ${[1.5, true]}"`, "5 years, [1.5, true]"},
+		{`let greet = fn(who) { "hi ${who}" }; "${greet("${1 + 1}")}"`, "hi 2"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"true", true},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 != 2", true},
+		{"(1 < 2) == true", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!(if (false) { 5; })", true},
+		{"2 <= 2", true},
+		{"1 >= 2", false},
+		{"1 < 2 && 2 < 3", true},
+		{`"abc" == "abc"`, true},
+		{`"abc" < "b"`, true},
+		{`[1, "a", {"k": [2]}] == [1, "a", {"k": [2]}]`, true},
+		{`[1, 2] != [1, 3]`, true},
+		{"true && 0", true},
+		{"false || (if (false) { 1 })", false},
+		{"let n = 0; let bump = fn() { n += 1; true }; false && bump(); true || bump(); n == 0", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", 10},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 > 2) { 10 }", evaluator.NULL},
+		{"if ((if (false) { 10 })) { 10 } else { 20 }", 20},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"return 10; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCallingFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let fivePlusTen = fn() { 5 + 10; }; fivePlusTen();", 15},
+		{"let earlyExit = fn() { return 99; 100; }; earlyExit();", 99},
+		{"let noReturn = fn() { }; noReturn();", evaluator.NULL},
+		{"let sum = fn(a, b) { let c = a + b; c; }; sum(1, 2) + sum(3, 4);", 10},
+		{"let globalNum = 10; let minusOne = fn() { let num = 1; globalNum - num; }; minusOne();", 9},
+		{"fn(x) { x; }(5)", 5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestDefaultAndRestParameters(t *testing.T) {
+	tests := []vmTestCase{
+		{"let add = fn(x, y = 10) { x + y; }; add(5);", 15},
+		{"let add = fn(x, y = 10) { x + y; }; add(5, 1);", 6},
+		{"let f = fn(x, y = x * 2) { y; }; f(4);", 8},
+		{"let count = fn(...args) { len(args); }; count(1, 2, 3);", 3},
+		{"let f = fn(x, y = 2, ...rest) { x + y + len(rest); }; f(1);", 3},
+		{"let f = fn(x, ...rest) { let y = 5; x + rest[1] + y; }; f(1, 2, 3);", 9},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{"let newAdder = fn(a, b) { fn(c) { a + b + c }; }; let adder = newAdder(1, 2); adder(8);", 11},
+		{`
+		let wrapper = fn() {
+			let countDown = fn(x) {
+				if (x == 0) { return 0; } else { countDown(x - 1); }
+			};
+			countDown(1);
+		};
+		wrapper();
+		`, 0},
+		{`
+		let fibonacci = fn(x) {
+			if (x == 0) { return 0; }
+			if (x == 1) { return 1; }
+			fibonacci(x - 1) + fibonacci(x - 2);
+		};
+		fibonacci(15);
+		`, 610},
+		{"let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } }; count(9000)", 9000},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"let i = 0; while (i < 5) { let i = i + 1; }; i", 5},
+		{"let i = 0; while (true) { let i = i + 1; if (i == 3) { break; } }; i", 3},
+		{"let i = 0; let n = 0; while (i < 5) { let i = i + 1; if (i == 2) { continue; } let n = n + i; }; n", 13},
+		{"let sum = fn(arr) { let total = 0; for (x in arr) { let total = total + x; }; total }; sum([1, 2, 3, 4])", 10},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10; } } }; f()", 20},
+		{"let f = fn() { let n = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } let n = n + x; }; n }; f()", 8},
+		{"let n = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } let n = n + x * y; } }; n", 30},
+		{"let x = 1; for (x in [5, 6]) { }; x", 6},
+		{"if (true) { while (false) { } }", evaluator.NULL},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []vmTestCase{
+		{"let x = 1; x = 5; x", 5},
+		{"let x = 1; let y = 2; x = y = 7; x + y", 14},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"let n = 0; let inc = fn() { n += 1 }; inc(); inc(); n", 2},
+		{"let f = fn() { let n = 5; n = 6; n }; f()", 6},
+		{"let f = fn() { let c = 0; let inc = fn() { c += 1 }; inc(); inc(); c }; f()", 2},
+		{"let counter = fn() { let c = 0; fn() { c += 1 } }; let a = counter(); let b = counter(); a(); a(); b(); a() * 10 + b()", 32},
+		{"let f = fn(x) { let g = fn() { fn() { x = x * 2 } }; g()(); g()(); x }; f(3)", 12},
+		{"let f = fn() { let x = 1; let get = fn() { x }; let x = 2; get() }; f()", 2},
+		{"let f = fn() { let i = 0; let total = 0; while (i < 4) { i += 1; total += i; }; total }; f()", 10},
+		{"let arr = [1, 2, 3]; arr[1] = 20; arr[1]", 20},
+		{"let arr = [[1], [2]]; arr[1][0] += 5; arr[1][0]", 7},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] += 10; h["a"] + h["b"]`, 13},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"5 + true;", &object.Error{Message: "type mismatch: INTEGER + BOOLEAN"}},
+		{"-true", &object.Error{Message: "unknown operator: -BOOLEAN"}},
+		{"let zero = 0; 1 / zero", &object.Error{Message: "division by zero"}},
+		{"[1, 2, 3][3]", &object.Error{Message: "index out of bounds!"}},
+		{`{"name": "Monkey"}[fn(x) { x }];`, &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{"fn(a) { a; }();", &object.Error{Message: "wrong number of arguments to `<anonymous>`: want=1, got=0"}},
+		{"let f = fn(a, b = 1) { a; }; f(1, 2, 3);", &object.Error{Message: "wrong number of arguments to `f`: want=1..2, got=3"}},
+		{"let f = fn(a, ...rest) { a; }; f();", &object.Error{Message: "wrong number of arguments to `f`: want=1+, got=0"}},
+		{`len(1)`, &object.Error{Message: "argument to `len` not supported, got INTEGER"}},
+		{"for (x in 5) { x }", &object.Error{Message: "cannot iterate over INTEGER"}},
+		{"let arr = [1]; arr[1] = 2", &object.Error{Message: "index out of bounds!"}},
+		{"5 % 0", &object.Error{Message: "division by zero"}},
+		{"1.5 % 0", &object.Error{Message: "division by zero"}},
+		{"if (false) { let z = 1 }; z", &object.Error{Message: "identifier not found: z"}},
+		{"let f = fn(x) { if (x) { let a = 1 }; a }; f(true); f(false)", &object.Error{Message: "identifier not found: a"}},
+		{"let f = fn(x) { if (x) { let a = 1 }; fn() { a } }; f(false)()", &object.Error{Message: "identifier not found: a"}},
+		{"let f = fn() { g() }; f(); let g = fn() { 1 };", &object.Error{Message: "identifier not found: g"}},
+		{"let x = x + 1; x", &object.Error{Message: "identifier not found: x"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLetRefersToShadowedBinding(t *testing.T) {
+	runVmTests(t, []vmTestCase{
+		{"let x = 1; let x = x + 1; x", 2},
+		{"let f = fn() { let y = 2; let y = y * 3; y }; f()", 6},
+	})
+}
+
+func TestBuiltinsAcrossLines(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	globals := make([]object.Object, GlobalsSize)
+	var constants []object.Object
+
+	lines := []struct {
+		input    string
+		compiles bool
+	}{
+		{`len("abc"); undefinedVar = 1`, false},
+		{`len("abcd")`, true},
+	}
+
+	for _, line := range lines {
+		comp := compiler.NewWithState(symbolTable, constants)
+		if err := comp.Compile(parse(line.input)); err != nil {
+			if line.compiles {
+				t.Fatalf("%q: compiler error: %s", line.input, err)
+			}
+			continue
+		}
+
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+
+		machine := NewWithGlobalsStore(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			t.Fatalf("%q: vm error: %s", line.input, err)
+		}
+		testExpectedObject(t, line.input, 4, machine.LastPoppedStackElem())
+	}
+}
+
+func TestTryCatchFinally(t *testing.T) {
+	tests := []vmTestCase{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 + true } catch (e) { 2 }`, 2},
+		{`try { throw "boom"; 1 } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom"; } catch (e) { e["kind"] }`, "Error"},
+		{`try { [1][5] } catch (e) { e["kind"] }`, "IndexError"},
+		{`try { -true } catch (e) { e["message"] }`, "unknown operator: -BOOLEAN"},
+		{`try { len(1, 2) } catch (e) { e["kind"] }`, "ArgumentError"},
+		{`try { throw {"kind": "ValueError", "message": "bad"} } catch (e) { e["kind"] + ": " + e["message"] }`, "ValueError: bad"},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e["message"] }`, "inner"},
+		{`let f = fn() { throw "deep"; }; try { f() } catch { 7 }`, 7},
+		{`let x = 1; try { let x = 2; } finally { let x = 3; }; x`, 3},
+		{`let f = fn() { try { return 1; } finally { 2 } }; f()`, 1},
+		{`let f = fn() { try { throw "x" } finally { return 5; } }; f()`, 5},
+		{`let f = fn() { try { throw "x" } catch (e) { return e["message"]; }; 10 }; f()`, "x"},
+		{`1 + try { 2 } catch { 3 } + try { throw 1 } catch { 4 }`, 7},
+		{`try { map([1], fn(x) { throw "cb" }) } catch (e) { e["message"] }`, "cb"},
+		{`let n = 0; let f = fn() { try { return 1 } finally { n += 10 } }; f() + n`, 11},
+		{`let n = 0; for (x in [1, 2, 3]) { try { if (x == 2) { continue } if (x == 3) { break } } finally { n += x } }; n`, 6},
+		{`let f = fn() { try { try { return 1 } finally { throw "a" } } catch (e) { e["message"] } }; f()`, "a"},
+		{`let e = 1; try { throw 2 } catch (e) { e }; e`, 1},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedKind    string
+	}{
+		{`throw "boom"; 5`, "boom", "Error"},
+		{`throw 5`, "5", "Error"},
+		{`try { throw "a" } finally { 1 }`, "a", "Error"},
+		{`try { 1 } finally { throw "b" }`, "b", "Error"},
+		{`try { throw "a" } catch (e) { throw "c" }`, "c", "Error"},
+		{`try { 1 + true } finally { 1 }`, "type mismatch: INTEGER + BOOLEAN", "TypeError"},
+	}
+
+	for _, tt := range tests {
+		result, err := run(parse(tt.input))
+		if err != nil {
+			t.Fatalf("%q: vm error: %s", tt.input, err)
+		}
+
+		errObj, ok := result.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, result, result)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+		if errObj.Kind != tt.expectedKind {
+			t.Errorf("%q: wrong error kind. expected=%q, got=%q", tt.input, tt.expectedKind, errObj.Kind)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   *object.Limits
+		expected string
+	}{
+		{"let f = fn() { f() }; f();", nil, "maximum call depth exceeded: 10000"},
+		{"let f = fn() { f() }; f();", &object.Limits{MaxDepth: evaluator.DefaultMaxDepth}, "maximum call depth exceeded: 10000"},
+		{"let f = fn(n) { let a = n; let b = n; f(n + 1) }; f(0);", &object.Limits{MaxDepth: 5000}, "maximum call depth exceeded: 5000"},
+		{"let f = fn() { f() }; f();", &object.Limits{MaxDepth: 10}, "maximum call depth exceeded: 10"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(100);", &object.Limits{MaxSteps: 50}, "step limit exceeded: 50"},
+		{"let f = fn(x) { map([x], f) }; f(1);", &object.Limits{MaxDepth: 10}, "maximum call depth exceeded: 10"},
+		{"map(range(100), fn(x) { x * 2 });", &object.Limits{MaxSteps: 50}, "step limit exceeded: 50"},
+		{"let f = fn() { f() }; try { f() } catch (e) { 1 }", &object.Limits{MaxDepth: 10}, "maximum call depth exceeded: 10"},
+		{"let f = fn() { try { f() } finally { return 1; } }; f()", &object.Limits{MaxDepth: 10}, "maximum call depth exceeded: 10"},
+	}
+
+	for _, tt := range tests {
+		comp := compiler.New()
+		if err := comp.Compile(parse(tt.input)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		machine := New(comp.Bytecode())
+		machine.SetLimits(tt.limits)
+
+		err := machine.Run()
+		errObj, ok := err.(*object.Error)
+		if !ok {
+			t.Errorf("%q: expected *object.Error. got=%T (%v)", tt.input, err, err)
+			continue
+		}
+		if errObj.Message != tt.expected || !errObj.Fatal {
+			t.Errorf("%q: wrong error. expected=%q, got=%q (fatal=%t)", tt.input, tt.expected, errObj.Message, errObj.Fatal)
+		}
+	}
+}
+
+func TestCheckOverflow(t *testing.T) {
+	comp := compiler.New()
+	if err := comp.Compile(parse("let min = -9223372036854775807 - 1; [min - 1, -min]")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	machine := New(comp.Bytecode())
+	machine.SetLimits(&object.Limits{CheckOverflow: true})
+
+	err := machine.Run()
+	if errObj, ok := err.(*object.Error); !ok || errObj.Message != "integer overflow: -9223372036854775808 - 1" {
+		t.Errorf("expected integer overflow error. got=%v", err)
+	}
+}
+
+// TestEngineParity runs programs from the evaluator's test suite through both engines
+// and checks they produce the same result.
+func TestEngineParity(t *testing.T) {
+	inputs := []string{
+		"5 + 5 + 5 + 5 - 10",
+		"(5 + 10 * 2 + 15 / 3) * 2 + -10",
+		"(1 > 2) == false",
+		"!!5",
+		"if (1 > 2) { 10 } else { 20 }",
+		"if (false) { 10 }",
+		"9; return 2 * 5; 9;",
+		"5 + true; 5;",
+		"true + false;",
+		`"Hello" - "World"`,
+		"let a = 5; let b = a; let c = a + b + 5; c;",
+		"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));",
+		"let newAdder = fn(x) { fn(y) { x + y }; }; let addTwo = newAdder(2); addTwo(2);",
+		"let add = fn(x, y) { x + y; }; add(1);",
+		"let f = fn(x, y = 3, ...rest) { [x, y, rest]; }; f(1, 2, 3, 4);",
+		`"Hello" + " " + "World!"`,
+		`len("hello world")`,
+		`len("one", "two")`,
+		`rest([1, 2, 3])`,
+		`push([], 1)`,
+		`first(1)`,
+		"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
+		"[1, 2, 3][-1]",
+		`let key = "foo"; {"foo": 5}[key]`,
+		`{"foo": 5}["bar"]`,
+		`{true: 5}[true]`,
+		`let count = fn(s) { let n = 0; for (c in s) { let n = n + 1; }; n }; count("héllo")`,
+		`let f = fn(h) { let out = ""; for (k in h) { let out = out + k; }; out }; f({"b": 1, "a": 2, "c": 3})`,
+		"let i = 0; while (i < 100) { let i = i + 7; }; i",
+		"let x = 2; x *= x += 1; x",
+		`[7 % 3, -7 % 3, 2 ** 10, 3 >= 3, 3 <= 2, 1 && 2, false || {}["a"]]`,
+		"true && 1 + true",
+		"[1 + 2.5, 3.0 * 2, 10 % 3.5, 1 == 1.0, int(9.99), float(\"1e-3\"), ceil(0.1)]",
+		`{1: "one"}[1.0]`,
+		`let s = "na\u{ef}ve\t\"x\""; [len(s), s[2], s]`,
+		"`raw\\n` + \"日本\"[1]",
+		`"abc"[5]`,
+		`let x = 2; "x=${x}, half=${x / 4.0}, ${if (x > 1) { "big" }}, ${"nested ${x * 3}"}"`,
+		`"${1 + true}"`,
+		`let words = ["pear", "apple"]; [words[0] > words[1], words[1] <= "apple", "x" == "x", [words] == [["pear", "apple"]]]`,
+		`let h = {}; h["a"] += 1`,
+		`let s = "ab"; s[0] = "c"`,
+		`let h = {"z": 1, "y": 2, "z": 0}; h["x"] = 3; [h, {2: "b", 1: "a"}]`,
+		`let conf = merge({"port": 80, "debug": false}, {"debug": true}); [keys(conf), values(conf), entries(delete(conf, "port")), has(conf, "port"), len(conf)]`,
+		`has({}, [])`,
+		`let n = 10; [map([1, 2], fn(x) { x + n }), filter(range(10), fn(x) { x % 3 == 0 }), reduce(["a", "b"], fn(a, s) { a + s }), map(["a", "bc"], len)]`,
+		`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; map(range(1, 6), fact)`,
+		`let byLen = fn(a, b) { len(a) < len(b) }; [sort(["ccc", "a", "bb"], byLen), sort([3, 1, 2]), reverse("abc"), zip([1, 2], [3])]`,
+		`[find([1, 2, 3], fn(x) { x > 1 }), any([1, 2], fn(x) { x > 5 }), all([1, 2], fn(x) { x > 0 })]`,
+		`map([[1, 2], [3]], fn(xs) { reduce(map(xs, fn(x) { x * x }), fn(a, b) { a + b }, 0) })`,
+		`let f = fn(x) { let y = x * 2; y + 1 }; let g = fn() { map([1, 2, 3], f) }; [g(), g()]`,
+		`map([1, "a"], fn(x) { x * 2 })`,
+		`sort([2, "a", 1])`,
+		`map([1, 2], fn(x, y) { x })`,
+		`reduce([], fn(a, b) { a + b })`,
+		`let a = [1, 2, 3, 4, 5]; [a[1:3], a[:-2], a[::2], a[::-1], a[3:0:-1], a[-1], "héllo"[1:], "abc"[-1], a[:]]`,
+		`let a = [1, 2, 3]; a[-1] = 9; a`,
+		"[1, 2, 3][1:2:0]",
+		`"abc"[1:"x"]`,
+		`[null, null ?? 5, 0 ?? 5, false ?? 1, null ?? null ?? 3, null == {}["a"]]`,
+		`let h = {"a": {"b": {"c": 1}}}; [h?.["a"]?.["b"]?.["c"], h?.["x"]?.["b"]?.["c"], h["x"]?.["c"] ?? "none"]`,
+		`let n = 0; let key = fn() { n += 1; "k" }; let f = null; [null?.[key()], null?.[1:], f?.(key()), n]`,
+		`let h = {"on": fn(x) { x + 1 }}; [h["on"]?.(1), h["off"]?.(1), "abc"?.[1:], "abc"?.[-1]]`,
+		`let f = fn(h) { h?.["v"] ?? "default" }; [f({"v": 1}), f(null), f({})]`,
+		`null(1)`,
+		"// adds one\nlet inc = fn(x) { x + 1 }; let anon = fn() { 1 }; [help(inc), help(anon), help(len), inc(1)]",
+		`help(1)`,
+		`[try { throw {"message": "m"} } catch (e) { e }, try { 1 } finally { 2 }, try { 1 + true } catch { 3 }]`,
+		`let f = fn(x) { try { if (x) { throw x } ; "ok" } catch (e) { e["message"] } finally { "ignored" } }; [f(false), f("no")]`,
+		`try { throw [1, 2] } catch (e) { e["message"] }`,
+		`let f = fn() { let c = 0; let inc = fn() { c += 1 }; inc(); [inc(), c] }; f()`,
+		`let fs = []; for (i in [1, 2]) { let fs = push(fs, fn() { i }) }; map(fs, fn(f) { f() })`,
+		"let f = fn() { g() }; let g = fn() { 1 }; f()",
+		"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } }; let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } }; [isEven(10), isOdd(7)]",
+		"if (false) { undefinedVar }; 5",
+		"undefinedVar",
+		"while (true) { break }",
+		"for (x in [1, 2]) { x }",
+		"let f = fn() { for (x in []) { x } }; f()",
+		"let f = fn() { g() }; f(); let g = fn() { 1 };",
+	}
+
+	for _, input := range inputs {
+		program := parse(input)
+
+		want := evaluator.Eval(program, object.NewEnvironment())
+
+		got, err := run(program)
+		if err != nil {
+			t.Errorf("%q: vm error: %s", input, err)
+			continue
+		}
+
+		if want.Inspect() != got.Inspect() {
+			t.Errorf("%q: engines disagree. evaluator=%q, vm=%q", input, want.Inspect(), got.Inspect())
+		}
+	}
+}
+
+// TestErrorTracebacks checks the VM reports where errors occurred and the calls leading to them like the evaluator
+func TestErrorTracebacks(t *testing.T) {
+	inputs := []string{
+		"let inner = fn(x) {\n\tx + missing;\n};\nlet outer = fn() {\n\tinner(1);\n};\nouter();",
+		"let f = fn(n) {\n\tif (n == 0) { missing } else { f(n - 1) }\n};\nf(50);",
+		"let a = [1, 2];\n1 +\n  a[1] * true",
+		"let f = fn(x) { x };\nf(1,\n  2)",
+		"let f = fn() { throw \"no\" };\nlet g = fn() { try { f() } finally { 1 } };\ng()",
+		"map([1, 2], fn(x) {\n  if (x > 1) { x + \"a\" } else { x }\n})",
+		"let s = \"${1 + \n true}\"",
+	}
+
+	for _, input := range inputs {
+		program := parse(input)
+
+		want, ok := evaluator.Eval(program, object.NewEnvironment()).(*object.Error)
+		if !ok {
+			t.Fatalf("%q: evaluator didn't return an error", input)
+		}
+
+		got, err := run(program)
+		if err != nil {
+			t.Errorf("%q: vm error: %s", input, err)
+			continue
+		}
+		errObj, ok := got.(*object.Error)
+		if !ok {
+			t.Errorf("%q: vm didn't return an error. got=%T (%+v)", input, got, got)
+			continue
+		}
+
+		if want.Traceback() != errObj.Traceback() {
+			t.Errorf("%q: engines disagree.\nevaluator=%q\nvm=%q", input, want.Traceback(), errObj.Traceback())
+		}
+	}
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		result, err := run(parse(tt.input))
+		if err != nil {
+			t.Fatalf("%q: vm error: %s", tt.input, err)
+		}
+
+		testExpectedObject(t, tt.input, tt.expected, result)
+	}
+}
+
+// run compiles and executes program, returning Monkey runtime errors as the result
+func run(program *ast.Program) (object.Object, error) {
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return nil, err
+	}
+
+	vm := New(comp.Bytecode())
+	if err := vm.Run(); err != nil {
+		if errObj, ok := err.(*object.Error); ok {
+			return errObj, nil
+		}
+		return nil, err
+	}
+
+	return vm.LastPoppedStackElem(), nil
+}
+
+func parse(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
+
+func testExpectedObject(t *testing.T, input string, expected interface{}, actual object.Object) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		result, ok := actual.(*object.Integer)
+		if !ok {
+			t.Errorf("%q: object is not Integer. got=%T (%+v)", input, actual, actual)
+			return
+		}
+		if result.Value != int64(expected) {
+			t.Errorf("%q: object has wrong value. got=%d, want=%d", input, result.Value, expected)
+		}
+
+	case float64:
+		result, ok := actual.(*object.Float)
+		if !ok {
+			t.Errorf("%q: object is not Float. got=%T (%+v)", input, actual, actual)
+			return
+		}
+		if result.Value != expected {
+			t.Errorf("%q: object has wrong value. got=%g, want=%g", input, result.Value, expected)
+		}
+
+	case string:
+		result, ok := actual.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", input, actual, actual)
+			return
+		}
+		if result.Value != expected {
+			t.Errorf("%q: object has wrong value. got=%q, want=%q", input, result.Value, expected)
+		}
+
+	case bool:
+		result, ok := actual.(*object.Boolean)
+		if !ok {
+			t.Errorf("%q: object is not Boolean. got=%T (%+v)", input, actual, actual)
+			return
+		}
+		if result.Value != expected {
+			t.Errorf("%q: object has wrong value. got=%t, want=%t", input, result.Value, expected)
+		}
+
+	case *object.Null:
+		if actual != evaluator.NULL {
+			t.Errorf("%q: object is not NULL. got=%T (%+v)", input, actual, actual)
+		}
+
+	case *object.Error:
+		errObj, ok := actual.(*object.Error)
+		if !ok {
+			t.Errorf("%q: object is not Error. got=%T (%+v)", input, actual, actual)
+			return
+		}
+		if errObj.Message != expected.Message {
+			t.Errorf("%q: wrong error message. expected=%q, got=%q", input, expected.Message, errObj.Message)
+		}
+	}
+}
@@ -1,33 +1,56 @@
 package lexer
 
 import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
 )
 
 // Lexer is synonymous with Tokenizer. Given a string input it reads the tokens.
+// The input is read as UTF-8, one rune at a time.
 type Lexer struct {
+	filename     string
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (points to NEXT char after current)
-	ch           byte // current char under examination
+	ch           rune // current char under examination
+	line         int  // line of the current char, starting at 1
+	column       int  // column of the current char in runes, starting at 1
+
+	interpolations []int // brace depth inside each open ${ } of a string, innermost last
 }
 
 // Returns Lexer for input string. This Lexer can read the input string's tokens
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile returns a Lexer for input read from the named file, token positions will carry the file name
+func NewFile(filename, input string) *Lexer {
+	l := &Lexer{filename: filename, input: input, line: 1}
 	l.readChar() // Initialize ch, position and readPosition
 	return l
 }
 
 // Reads next char of input string
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
 }
 
 // Returns the Token Type and Literal of the char ch under examination
@@ -36,6 +59,8 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipWhiteSpace()
 
+	pos := l.currentPosition()
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -50,9 +75,9 @@ func (l *Lexer) NextToken() token.Token {
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.newOperatorToken(token.PLUS, token.PLUS_ASSIGN)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.newOperatorToken(token.MINUS, token.MINUS_ASSIGN)
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -66,43 +91,92 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			tok.Type = token.COMMENT
 			tok.Literal = l.readComment()
+			tok.Pos, tok.End = pos, l.currentPosition()
+			return tok // Returning early so the newline ending the comment is not consumed
+		} else if l.peekChar() == '*' {
+			return l.readBlockComment(pos)
 		} else {
-			tok = newToken(token.SLASH, l.ch)
+			tok = l.newOperatorToken(token.SLASH, token.SLASH_ASSIGN)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			l.readChar()
+			tok = token.Token{Type: token.POWER, Literal: "**"}
+		} else {
+			tok = l.newOperatorToken(token.ASTERISK, token.ASTERISK_ASSIGN)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		tok = l.newOperatorToken(token.LT, token.LT_EQ)
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		tok = l.newOperatorToken(token.GT, token.GT_EQ)
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL, Literal: "?."}
+		default:
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '&':
+		tok = l.newDoubleCharToken('&', token.AND)
+	case '|':
+		tok = l.newDoubleCharToken('|', token.OR)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '}':
+		if depth := len(l.interpolations) - 1; depth >= 0 {
+			if l.interpolations[depth] == 0 {
+				// The '}' closes an interpolation, the string it is in carries on after it
+				l.interpolations = l.interpolations[:depth]
+				return l.readString()
+			}
+			l.interpolations[depth]--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '{':
+		if depth := len(l.interpolations) - 1; depth >= 0 {
+			l.interpolations[depth]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		return l.readString() // Returning early since the string sets its own positions
+	case '`':
+		return l.readRawString() // Returning early since the string sets its own positions
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() == '.' && l.peekCharAt(2) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
+		tok.Pos, tok.End = pos, pos
+		return tok // Returning early since there is nothing left to read
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos, tok.End = pos, l.currentPosition()
 			return tok // Returning early since ch is advanced in l.readIdentifier()
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Pos, tok.End = pos, l.currentPosition()
 			return tok // Returning early since ch is advanced in l.readNumber()
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -110,14 +184,25 @@ func (l *Lexer) NextToken() token.Token {
 	}
 
 	l.readChar()
+	tok.Pos, tok.End = pos, l.currentPosition()
 	return tok
 }
 
 // Returns new token
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// currentPosition returns the source position of the char under examination
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
 // Returns identifier string starting from l.position
 func (l *Lexer) readIdentifier() string {
 	position := l.position
@@ -127,9 +212,10 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-// Returns true if ch is Letter, included '_' to accomodate identifiers like 'foo_bar'
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// Returns true if ch is Letter, included '_' to accomodate identifiers like 'foo_bar'.
+// Letters from any script are allowed, so 'größe' and 'π' are identifiers too.
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
 // Moves position to skip whitespace
@@ -140,41 +226,261 @@ func (l *Lexer) skipWhiteSpace() {
 }
 
 // Returns true if ch is Digit
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-// Returns number string from l.position
-func (l *Lexer) readNumber() string {
+// Returns number string from l.position and whether it is an INT or a FLOAT.
+// A FLOAT has a fraction, an exponent or both, like 1.5, 2e10 or 6.02e-23.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
+
+	l.readDigits()
+
+	// The '.' must be followed by a digit, so 1.foo is left for the parser to reject
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		digitsAt := 1
+		if l.peekChar() == '+' || l.peekChar() == '-' {
+			digitsAt = 2
+		}
+		if isDigit(l.peekCharAt(digitsAt)) {
+			tokenType = token.FLOAT
+			for i := 0; i < digitsAt; i++ {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+
+	return l.input[position:l.position], tokenType
+}
+
+// readDigits advances past a run of digits
+func (l *Lexer) readDigits() {
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+}
+
+// newOperatorToken returns a token for the operator at the current char,
+// or for its two char form, like += or <=, if it is followed by '='
+func (l *Lexer) newOperatorToken(operator, withEquals token.TokenType) token.Token {
+	if l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		return token.Token{Type: withEquals, Literal: string(ch) + string(l.ch)}
+	}
+	return newToken(operator, l.ch)
+}
+
+// newDoubleCharToken returns a token for operators like && that are the current char written twice.
+// The char on its own is ILLEGAL.
+func (l *Lexer) newDoubleCharToken(ch rune, tokenType token.TokenType) token.Token {
+	if l.peekChar() != ch {
+		return newToken(token.ILLEGAL, l.ch)
+	}
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: string(ch) + string(ch)}
 }
 
 // Returns char in next position
-func (l *Lexer) peekChar() byte {
-	if l.readPosition < len(l.input) {
-		return l.input[l.readPosition]
-	} else {
-		return 0
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// Returns char n positions after the current one
+func (l *Lexer) peekCharAt(n int) rune {
+	position := l.readPosition
+	for ; position < len(l.input); n-- {
+		ch, width := utf8.DecodeRuneInString(l.input[position:])
+		if n == 1 {
+			return ch
+		}
+		position += width
+	}
+	return 0
+}
+
+// readString returns a STRING token for the double quoted string starting at the current char,
+// with escape sequences replaced by the chars they stand for. A string with an invalid escape
+// sequence is an ILLEGAL token located at that sequence, one without a closing quote is an ILLEGAL
+// token holding the rest of the input.
+//
+// Strings containing ${ } interpolations are split into a TEMPLATE_HEAD up to the first "${",
+// the tokens of each interpolated expression, TEMPLATE_MIDDLE tokens between one "}" and the next "${",
+// and a TEMPLATE_TAIL from the last "}" to the closing quote. readString is also called on the '}'
+// that closes an interpolation to read the part of the string after it.
+func (l *Lexer) readString() token.Token {
+	start := l.currentPosition()
+	continued := l.ch == '}'
+
+	var out strings.Builder
+	var illegal *token.Token
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			l.readChar()
+			if illegal != nil {
+				illegal.End = l.currentPosition()
+				return *illegal
+			}
+
+			tokenType := token.TokenType(token.STRING)
+			if continued {
+				tokenType = token.TEMPLATE_TAIL
+			}
+			return token.Token{Type: tokenType, Literal: out.String(), Pos: start, End: l.currentPosition()}
+
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteRune(l.ch)
+				continue
+			}
+			l.readChar()
+			l.readChar()
+			l.interpolations = append(l.interpolations, 0)
+
+			if illegal != nil {
+				illegal.End = l.currentPosition()
+				return *illegal
+			}
+
+			tokenType := token.TokenType(token.TEMPLATE_HEAD)
+			if continued {
+				tokenType = token.TEMPLATE_MIDDLE
+			}
+			return token.Token{Type: tokenType, Literal: out.String(), Pos: start, End: l.currentPosition()}
+
+		case 0:
+			return l.unterminatedString(start)
+
+		case '\\':
+			escape := l.currentPosition()
+			ch, ok := l.readEscape()
+			if !ok && illegal == nil {
+				illegal = &token.Token{Type: token.ILLEGAL, Literal: l.input[escape.Offset:l.readPosition], Pos: escape}
+			}
+			out.WriteRune(ch)
+
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape reads the escape sequence started by the backslash at the current char, leaving the last
+// char of the sequence as the current one. It returns the char the sequence stands for and whether it is valid.
+func (l *Lexer) readEscape() (rune, bool) {
+	switch l.peekChar() {
+	case 'n':
+		l.readChar()
+		return '\n', true
+	case 't':
+		l.readChar()
+		return '\t', true
+	case 'r':
+		l.readChar()
+		return '\r', true
+	case '"':
+		l.readChar()
+		return '"', true
+	case '$':
+		l.readChar()
+		return '$', true
+	case '\\':
+		l.readChar()
+		return '\\', true
+	case 'u':
+		// \u{...} holds the hex code point of a Unicode char, like \u{1F600}
+		l.readChar()
+		if l.peekChar() != '{' {
+			return 0, false
+		}
+		l.readChar()
+
+		digits := l.readPosition
+		for isHexDigit(l.peekChar()) && l.readPosition-digits < 6 {
+			l.readChar()
+		}
+		code, err := strconv.ParseUint(l.input[digits:l.readPosition], 16, 32)
+		if err != nil || l.peekChar() != '}' {
+			return 0, false
+		}
+		l.readChar()
+
+		return rune(code), utf8.ValidRune(rune(code))
+	case '\n', 0:
+		return 0, false
+	default:
+		l.readChar()
+		return 0, false
 	}
 }
 
-// readString returns the characters within quotes
-func (l *Lexer) readString() string {
+// readRawString returns a STRING token for the backtick quoted string starting at the current char.
+// Raw strings can span lines and have no escape sequences, their text is taken as is.
+func (l *Lexer) readRawString() token.Token {
+	start := l.currentPosition()
 	position := l.position + 1
+
 	for {
 		l.readChar()
+		if l.ch == '`' || l.ch == 0 {
+			break
+		}
+	}
+
+	if l.ch == 0 {
+		return l.unterminatedString(start)
+	}
+
+	literal := l.input[position:l.position]
+	l.readChar()
+
+	return token.Token{Type: token.STRING, Literal: literal, Pos: start, End: l.currentPosition()}
+}
 
-		// TODO: Add support for char escaping
+// readBlockComment reads a /* */ comment starting at start, which may span several lines.
+// A comment missing its closing */ is an ILLEGAL token running to the end of input.
+func (l *Lexer) readBlockComment(start token.Position) token.Token {
+	l.readChar()
+	position := l.position + 1
 
-		if l.ch == '"' || l.ch == 0 {
+	for {
+		l.readChar()
+		if l.ch == 0 {
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[start.Offset:l.position], Pos: start, End: l.currentPosition()}
+		}
+		if l.ch == '*' && l.peekChar() == '/' {
 			break
 		}
 	}
-	return l.input[position:l.position]
+
+	literal := l.input[position:l.position]
+	l.readChar()
+	l.readChar()
+
+	return token.Token{Type: token.BLOCK_COMMENT, Literal: literal, Pos: start, End: l.currentPosition()}
+}
+
+// unterminatedString returns the ILLEGAL token for a string starting at start that reached the end of input
+func (l *Lexer) unterminatedString(start token.Position) token.Token {
+	return token.Token{Type: token.ILLEGAL, Literal: l.input[start.Offset:l.position], Pos: start, End: l.currentPosition()}
+}
+
+// Returns true if ch is a hexadecimal digit
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
 // readComment returns the comment text
@@ -15,7 +15,7 @@ func TestNextToken(t *testing.T) {
 	};
 
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 
 	if (5 < 10) {
@@ -31,6 +31,14 @@ func TestNextToken(t *testing.T) {
 	"foo bar"
 	[1, 2];	
 	{"foo": "bar"}	
+	try { throw e; } catch (e) {} finally {}
+	...rest ..
+	while for in break continue
+	x += 1 -= *= /=
+	a && b || c <= >= % ** & |
+	1.5 0.25 2e10 6.02E-23 1e+3 7. 3e
+	null ?? h?.[0] f?.() ?
+	macro(x) {}
 	// comment
 	`
 
@@ -124,6 +132,74 @@ func TestNextToken(t *testing.T) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.TRY, "try"},
+		{token.LBRACE, "{"},
+		{token.THROW, "throw"},
+		{token.IDENT, "e"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.CATCH, "catch"},
+		{token.LPAREN, "("},
+		{token.IDENT, "e"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.FINALLY, "finally"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.ILLEGAL, "."},
+		{token.ILLEGAL, "."},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.IN, "in"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.SLASH_ASSIGN, "/="},
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.LT_EQ, "<="},
+		{token.GT_EQ, ">="},
+		{token.PERCENT, "%"},
+		{token.POWER, "**"},
+		{token.ILLEGAL, "&"},
+		{token.ILLEGAL, "|"},
+		{token.FLOAT, "1.5"},
+		{token.FLOAT, "0.25"},
+		{token.FLOAT, "2e10"},
+		{token.FLOAT, "6.02E-23"},
+		{token.FLOAT, "1e+3"},
+		{token.INT, "7"},
+		{token.ILLEGAL, "."},
+		{token.INT, "3"},
+		{token.IDENT, "e"},
+		{token.NULL, "null"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "h"},
+		{token.OPTIONAL, "?."},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.IDENT, "f"},
+		{token.OPTIONAL, "?."},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.ILLEGAL, "?"},
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
 		{token.COMMENT, " comment"},
 		{token.EOF, ""},
 	}
@@ -143,3 +219,192 @@ func TestNextToken(t *testing.T) {
 	}
 
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 10;
+if (x >= "a b") { // done
+}`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     string
+		expectedEnd     int
+	}{
+		{"let", "test.mk:1:1", 3},
+		{"x", "test.mk:1:5", 5},
+		{"=", "test.mk:1:7", 7},
+		{"10", "test.mk:1:9", 10},
+		{";", "test.mk:1:11", 11},
+		{"if", "test.mk:2:1", 14},
+		{"(", "test.mk:2:4", 16},
+		{"x", "test.mk:2:5", 17},
+		{">=", "test.mk:2:7", 20},
+		{"a b", "test.mk:2:10", 26},
+		{")", "test.mk:2:15", 27},
+		{"{", "test.mk:2:17", 29},
+		{" done", "test.mk:2:19", 37},
+		{"}", "test.mk:3:1", 39},
+		{"", "test.mk:3:2", 39},
+	}
+
+	l := NewFile("test.mk", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.String() != tt.expectedPos {
+			t.Errorf("tests[%d] - position wrong. expected=%q, got=%q", i, tt.expectedPos, tok.Pos.String())
+		}
+
+		if tok.End.Offset != tt.expectedEnd {
+			t.Errorf("tests[%d] - end offset wrong. expected=%d, got=%d", i, tt.expectedEnd, tok.End.Offset)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"tab\tnew\nline"`, token.STRING, "tab\tnew\nline"},
+		{`"say \"hi\" \\ bye"`, token.STRING, `say "hi" \ bye`},
+		{`"\u{48}\u{e9}\u{1F600}"`, token.STRING, "Hé😀"},
+		{`"héllo wörld"`, token.STRING, "héllo wörld"},
+		{"`raw \\n \"text\"\nover lines`", token.STRING, "raw \\n \"text\"\nover lines"},
+		{`"bad \q escape"`, token.ILLEGAL, `\q`},
+		{`"bad \u{110000}"`, token.ILLEGAL, `\u{110000}`},
+		{`"bad \u{zz}"`, token.ILLEGAL, `\u{`},
+		{`"unterminated`, token.ILLEGAL, `"unterminated`},
+		{"`unterminated", token.ILLEGAL, "`unterminated"},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("%s: tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%s: literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedEnd     int
+	}{
+		{"// line\nx", token.COMMENT, " line", 7},
+		{"/* block */ x", token.BLOCK_COMMENT, " block ", 11},
+		{"/* over\n * lines */", token.BLOCK_COMMENT, " over\n * lines ", 19},
+		{"/**/", token.BLOCK_COMMENT, "", 4},
+		{"/* a // b */", token.BLOCK_COMMENT, " a // b ", 12},
+		{"/*/ unterminated", token.ILLEGAL, "/*/ unterminated", 16},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("%q: tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q: literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.End.Offset != tt.expectedEnd {
+			t.Errorf("%q: end offset wrong. expected=%d, got=%d", tt.input, tt.expectedEnd, tok.End.Offset)
+		}
+	}
+
+	// the token after a block comment is read normally
+	l := New("/* c */ 1 / 2")
+	for _, expected := range []token.TokenType{token.BLOCK_COMMENT, token.INT, token.SLASH, token.INT, token.EOF} {
+		if tok := l.NextToken(); tok.Type != expected {
+			t.Errorf("tokentype wrong. expected=%q, got=%q", expected, tok.Type)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "let größe = π + 日本;\n\"é\" x"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     string
+	}{
+		{token.LET, "let", "1:1"},
+		{token.IDENT, "größe", "1:5"},
+		{token.ASSIGN, "=", "1:11"},
+		{token.IDENT, "π", "1:13"},
+		{token.PLUS, "+", "1:15"},
+		{token.IDENT, "日本", "1:17"},
+		{token.SEMICOLON, ";", "1:19"},
+		{token.STRING, "é", "2:1"},
+		{token.IDENT, "x", "2:5"},
+		{token.EOF, "", "2:6"},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos.String() != tt.expectedPos {
+			t.Errorf("tests[%d] - position wrong. expected=%q, got=%q", i, tt.expectedPos, tok.Pos.String())
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"Hi ${name}, ${ {"a": 1}["a"] + f("${x}") }!" "$5 \${x}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "Hi "},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_MIDDLE, ", "},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.PLUS, "+"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.TEMPLATE_HEAD, ""},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.RPAREN, ")"},
+		{token.TEMPLATE_TAIL, "!"},
+		{token.STRING, "$5 ${x}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
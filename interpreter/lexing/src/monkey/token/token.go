@@ -1,11 +1,35 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
-// A Token is made up of a TokenType and a Literal which is the actual value of that token
+// A Token is made up of a TokenType and a Literal which is the actual value of that token.
+// Pos and End locate the token in the source, End points just past its last character.
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
+	End     Position
+}
+
+// Position is a location in the source input
+type Position struct {
+	Filename string // empty when the input did not come from a file
+	Offset   int    // byte offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number, starting at 1
+}
+
+// IsValid returns true if the position was set by the lexer
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position as file:line:column, or line:column when there is no file name
+func (p Position) String() string {
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 // Constant variables to define Keywords and Operaters of Monkey Language
@@ -16,6 +40,7 @@ const (
 	// Identifiers + literals
 	IDENT = "IDENT" // Identifier string for - add, foo, bar, x, y, ...
 	INT   = "INT"   // 12345
+	FLOAT = "FLOAT" // 1.5, 2e10, 6.02e-23
 
 	// Operaters
 	ASSIGN   = "="
@@ -26,13 +51,27 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+	POWER    = "**"
 	LT       = "<"
 	GT       = ">"
+	LT_EQ    = "<="
+	GT_EQ    = ">="
+	AND      = "&&"
+	OR       = "||"
+	NULLISH  = "??"
+	OPTIONAL = "?." // followed by [ or ( to index or call only when the left side isn't null
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	ELLIPSIS  = "..."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -46,23 +85,51 @@ const (
 	LET      = "LET"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	NULL     = "NULL"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	MACRO    = "MACRO"
+
+	STRING        = "STRING"
+	COMMENT       = "COMMENT"       // a // comment, the literal is the text after the slashes
+	BLOCK_COMMENT = "BLOCK_COMMENT" // a /* */ comment, the literal is the text between the delimiters
 
-	STRING  = "STRING"
-	COMMENT = "COMMENT"
+	// Parts of a string with ${ } interpolations, around the tokens of the interpolated expressions
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"   // "text${
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE" // }text${
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"   // }text"
 )
 
 // Map to store language specific keywords
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"macro":    MACRO,
 }
 
 // Returns TokenType given ident string - keyword if present in map else IDENT to indicate user-defined identifier
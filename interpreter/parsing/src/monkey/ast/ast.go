@@ -7,16 +7,20 @@ import (
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
 )
 
-// Everything is a Node in the Abstract Syntax Tree
+// Everything is a Node in the Abstract Syntax Tree.
+// Pos and End give the Node's span in the source, End points just past its last character.
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
+	End() token.Position
 }
 
-// Statement Nodes
+// Statement Nodes, each carries the comments written around it
 type Statement interface {
 	Node
 	statementNode()
+	Comments() *Trivia
 }
 
 // Expression Nodes
@@ -28,6 +32,7 @@ type Expression interface {
 // Program is a Node that contains a slice of Statements, which are also Nodes
 type Program struct {
 	Statements []Statement
+	Dangling   []*Comment // comments after the last statement
 }
 
 func (p *Program) TokenLiteral() string {
@@ -38,6 +43,20 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
 // Returns the string of Statements in Program
 func (p *Program) String() string {
 	var out bytes.Buffer
@@ -54,10 +73,13 @@ type LetStatement struct {
 	Token token.Token // the token.LET token
 	Name  *Identifier
 	Value Expression
+	Trivia
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position  { return endOf(ls.Value, ls.Token) }
 
 // Returns the Statement String
 func (ls *LetStatement) String() string {
@@ -75,6 +97,10 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// Doc returns the text of the comments written right above the statement, which document the
+// value it binds, or "" if there are none
+func (ls *LetStatement) Doc() string { return docText(ls.Leading, ls.Pos()) }
+
 // Identifier is both a Node and an Expression
 type Identifier struct {
 	Token token.Token // the token.IDENT token
@@ -83,6 +109,8 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
+func (i *Identifier) End() token.Position  { return i.Token.End }
 
 func (i *Identifier) String() string {
 	return i.Value
@@ -91,10 +119,13 @@ func (i *Identifier) String() string {
 type ReturnStatement struct {
 	Token       token.Token // the `return` token
 	ReturnValue Expression
+	Trivia
 }
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position  { return endOf(rs.ReturnValue, rs.Token) }
 
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -114,10 +145,13 @@ func (rs *ReturnStatement) String() string {
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
 	Expression Expression
+	Trivia
 }
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position  { return posOf(es.Expression, es.Token) }
+func (es *ExpressionStatement) End() token.Position  { return endOf(es.Expression, es.Token) }
 
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
@@ -133,8 +167,22 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Position  { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position  { return il.Token.End }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// FloatLiteral is a floating point number such as 1.5 or 2e10
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position  { return fl.Token.End }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 // PrefixExpression is both a Node and an Expression
 type PrefixExpression struct {
 	Token    token.Token
@@ -144,6 +192,8 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position  { return endOf(pe.Right, pe.Token) }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -166,6 +216,8 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Pos() token.Position  { return posOf(ie.Left, ie.Token) }
+func (ie *InfixExpression) End() token.Position  { return endOf(ie.Right, ie.Token) }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -179,6 +231,29 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression stores Value into Target, an Identifier or IndexExpression.
+// Compound operators such as += combine the current value of Target with Value first.
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token
+	Target   Expression
+	Operator string // "=", "+=", "-=", "*=" or "/="
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position  { return posOf(ae.Target, ae.Token) }
+func (ae *AssignExpression) End() token.Position  { return endOf(ae.Value, ae.Token) }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+
 // Boolean is both a Node and an Expression
 type Boolean struct {
 	Token token.Token
@@ -187,8 +262,21 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) End() token.Position  { return b.Token.End }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+// NullLiteral is the null keyword
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) Pos() token.Position  { return nl.Token.Pos }
+func (nl *NullLiteral) End() token.Position  { return nl.Token.End }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 // IfExpression is both a Node and an Expression
 type IfExpression struct {
 	Token       token.Token // The 'if' token
@@ -199,6 +287,16 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -219,10 +317,15 @@ func (ie *IfExpression) String() string {
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
+	Rbrace     token.Token // the closing } token
+	Dangling   []*Comment  // comments after the last statement, before the closing }
+	Trivia
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position  { return closeOf(bs.Rbrace, bs.Token) }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -237,37 +340,91 @@ func (bs *BlockStatement) String() string {
 type FunctionLiteral struct {
 	Token      token.Token // the 'fn' token
 	Parameters []*Identifier
+	Defaults   map[string]Expression // default values of optional parameters, keyed by parameter name
+	Rest       *Identifier           // the trailing '...rest' parameter, nil if not variadic
 	Body       *BlockStatement
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
-
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ParameterList(fl.Parameters, fl.Defaults, fl.Rest))
 	out.WriteString(") ")
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
 
+// ParameterList formats function parameters the way they are written in source
+func ParameterList(params []*Identifier, defaults map[string]Expression, rest *Identifier) string {
+	list := []string{}
+	for _, p := range params {
+		if def, ok := defaults[p.Value]; ok {
+			list = append(list, p.String()+" = "+def.String())
+		} else {
+			list = append(list, p.String())
+		}
+	}
+
+	if rest != nil {
+		list = append(list, "..."+rest.String())
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// MacroLiteral defines a macro, its body runs on the unevaluated arguments of the calls it expands
+type MacroLiteral struct {
+	Token      token.Token // the 'macro' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode()      {}
+func (ml *MacroLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MacroLiteral) Pos() token.Position  { return ml.Token.Pos }
+func (ml *MacroLiteral) End() token.Position {
+	if ml.Body != nil {
+		return ml.Body.End()
+	}
+	return ml.Token.End
+}
+func (ml *MacroLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ml.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(ParameterList(ml.Parameters, nil, nil))
+	out.WriteString(") ")
+	out.WriteString(ml.Body.String())
+
+	return out.String()
+}
+
 // CallExpression is a Node and an Expression
 type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	Rparen    token.Token // the closing ')' token
+	Optional  bool        // written f?.(args), the call is skipped and gives null when f is null
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position  { return posOf(ce.Function, ce.Token) }
+func (ce *CallExpression) End() token.Position  { return closeOf(ce.Rparen, ce.Token) }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -277,6 +434,9 @@ func (ce *CallExpression) String() string {
 	}
 
 	out.WriteString(ce.Function.String())
+	if ce.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
@@ -292,16 +452,51 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.End }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// TemplateLiteral is a string with interpolated expressions, like "Hello ${name}".
+// Parts alternates between the text around the expressions, as StringLiterals, and the expressions.
+// It starts and ends with text, which may be empty.
+type TemplateLiteral struct {
+	Token token.Token // the TEMPLATE_HEAD token
+	Parts []Expression
+}
+
+func (tl *TemplateLiteral) expressionNode()      {}
+func (tl *TemplateLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TemplateLiteral) Pos() token.Position  { return tl.Token.Pos }
+func (tl *TemplateLiteral) End() token.Position {
+	return endOf(tl.Parts[len(tl.Parts)-1], tl.Token)
+}
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for i, part := range tl.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 // ArrayLiteral is a Node that represents a slice of Expressions
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
+	Rbracket token.Token // the closing ']' token
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position  { return closeOf(al.Rbracket, al.Token) }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -319,18 +514,25 @@ func (al *ArrayLiteral) String() string {
 
 // IndexExpression is an Expression Node for indexing into Arrays
 type IndexExpression struct {
-	Token token.Token // the `[` token
-	Left  Expression
-	Index Expression
+	Token    token.Token // the `[` token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // the closing `]` token
+	Optional bool        // written left?.[index], gives null when left is null
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return posOf(ie.Left, ie.Token) }
+func (ie *IndexExpression) End() token.Position  { return closeOf(ie.Rbracket, ie.Token) }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -338,20 +540,67 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression is an Expression Node for slicing Arrays and Strings with left[start:stop:step].
+// Omitted bounds are nil.
+type SliceExpression struct {
+	Token    token.Token // the `[` token
+	Left     Expression
+	Start    Expression
+	Stop     Expression
+	Step     Expression
+	Rbracket token.Token // the closing `]` token
+	Optional bool        // written left?.[start:stop], gives null when left is null
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Pos() token.Position  { return posOf(se.Left, se.Token) }
+func (se *SliceExpression) End() token.Position  { return closeOf(se.Rbracket, se.Token) }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	if se.Optional {
+		out.WriteString("?.")
+	}
+	out.WriteString("[")
+	for i, bound := range []Expression{se.Start, se.Stop, se.Step} {
+		if i > 0 && (i < 2 || bound != nil) {
+			out.WriteString(":")
+		}
+		if bound != nil {
+			out.WriteString(bound.String())
+		}
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 // HashLiteral is a hashmap Node
 type HashLiteral struct {
-	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Token  token.Token // the '{' token
+	Pairs  []HashPair  // in source order
+	Rbrace token.Token // the closing '}' token
+}
+
+// HashPair is a single key: value entry of a HashLiteral
+type HashPair struct {
+	Key   Expression
+	Value Expression
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position  { return closeOf(hl.Rbrace, hl.Token) }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -360,3 +609,181 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// ThrowStatement raises its Value as a catchable error
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression
+	Trivia
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *ThrowStatement) End() token.Position  { return endOf(ts.Value, ts.Token) }
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// TryExpression runs Block and hands any error it raises to Catch, Finally always runs last
+type TryExpression struct {
+	Token      token.Token // the 'try' token
+	Block      *BlockStatement
+	CatchParam *Identifier // binds the caught error, nil if the catch clause has no parameter
+	Catch      *BlockStatement
+	Finally    *BlockStatement
+}
+
+func (te *TryExpression) expressionNode()      {}
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) Pos() token.Position  { return te.Token.Pos }
+func (te *TryExpression) End() token.Position {
+	switch {
+	case te.Finally != nil:
+		return te.Finally.End()
+	case te.Catch != nil:
+		return te.Catch.End()
+	case te.Block != nil:
+		return te.Block.End()
+	}
+	return te.Token.End
+}
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch")
+		if te.CatchParam != nil {
+			out.WriteString("(" + te.CatchParam.String() + ")")
+		}
+		out.WriteString(" ")
+		out.WriteString(te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+
+	return out.String()
+}
+
+// WhileStatement runs Body for as long as Condition is truthy
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+	Trivia
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Position  { return ws.Token.Pos }
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return ws.Token.End
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// ForStatement runs Body once for every element of Iterable, bound to Variable
+type ForStatement struct {
+	Token    token.Token // the 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+	Trivia
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// BreakStatement ends the innermost loop
+type BreakStatement struct {
+	Token token.Token // the 'break' token
+	Trivia
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) End() token.Position  { return bs.Token.End }
+func (bs *BreakStatement) String() string       { return bs.TokenLiteral() + ";" }
+
+// ContinueStatement skips to the next iteration of the innermost loop
+type ContinueStatement struct {
+	Token token.Token // the 'continue' token
+	Trivia
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) End() token.Position  { return cs.Token.End }
+func (cs *ContinueStatement) String() string       { return cs.TokenLiteral() + ";" }
+
+// posOf returns where node starts, or where tok starts if node is missing
+func posOf(node Node, tok token.Token) token.Position {
+	if node != nil {
+		return node.Pos()
+	}
+	return tok.Pos
+}
+
+// endOf returns where node ends, or where tok ends if node is missing
+func endOf(node Node, tok token.Token) token.Position {
+	if node != nil {
+		return node.End()
+	}
+	return tok.End
+}
+
+// closeOf returns where the closing delimiter ends, or where the opening one ends if it was never parsed
+func closeOf(close, open token.Token) token.Position {
+	if close.Pos.IsValid() {
+		return close.End
+	}
+	return open.End
+}
@@ -27,3 +27,26 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestCommentText(t *testing.T) {
+	tests := []struct {
+		comment  token.Token
+		str      string
+		expected string
+	}{
+		{token.Token{Type: token.COMMENT, Literal: " hello "}, "// hello ", "hello"},
+		{token.Token{Type: token.BLOCK_COMMENT, Literal: " one line "}, "/* one line */", "one line"},
+		{token.Token{Type: token.BLOCK_COMMENT, Literal: "\n * first\n * second\n "}, "/*\n * first\n * second\n */", "first\nsecond"},
+		{token.Token{Type: token.BLOCK_COMMENT, Literal: "a\n  b"}, "/*a\n  b*/", "a\nb"},
+	}
+
+	for _, tt := range tests {
+		comment := &Comment{Token: tt.comment}
+		if comment.String() != tt.str {
+			t.Errorf("comment.String() wrong. got=%q, want=%q", comment.String(), tt.str)
+		}
+		if comment.Text() != tt.expected {
+			t.Errorf("comment.Text() wrong. got=%q, want=%q", comment.Text(), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+)
+
+// Comment is a // line comment or a /* */ block comment
+type Comment struct {
+	Token token.Token // the COMMENT or BLOCK_COMMENT token, its literal has no comment markers
+}
+
+func (c *Comment) TokenLiteral() string { return c.Token.Literal }
+func (c *Comment) Pos() token.Position  { return c.Token.Pos }
+func (c *Comment) End() token.Position  { return c.Token.End }
+
+// String returns the comment as it is written, markers included
+func (c *Comment) String() string {
+	if c.IsBlock() {
+		return "/*" + c.Token.Literal + "*/"
+	}
+	return "//" + c.Token.Literal
+}
+
+// IsBlock reports whether the comment is a /* */ comment
+func (c *Comment) IsBlock() bool { return c.Token.Type == token.BLOCK_COMMENT }
+
+// Text returns the lines of the comment without markers or surrounding blanks. The '*' that
+// commonly starts the lines of a block comment is removed as well.
+func (c *Comment) Text() string {
+	if !c.IsBlock() {
+		return strings.TrimSpace(c.Token.Literal)
+	}
+
+	lines := strings.Split(c.Token.Literal, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		lines[i] = line
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Trivia holds the comments attached to a statement, which don't change what it does
+type Trivia struct {
+	Leading  []*Comment // on the lines before the statement, followed by any from inside its expressions
+	Trailing []*Comment // after the statement, on the line where it ends
+}
+
+// Comments returns the comments attached to the statement
+func (t *Trivia) Comments() *Trivia { return t }
+
+// docText returns the text of the comments among leading that directly precede pos, on consecutive
+// lines with the last one ending on the line before pos or on the same line
+func docText(leading []*Comment, pos token.Position) string {
+	line := pos.Line
+	start, end := len(leading), len(leading)
+
+	for i := len(leading) - 1; i >= 0; i-- {
+		comment := leading[i]
+		if comment.Pos().Offset >= pos.Offset {
+			// moved here from inside the statement
+			start, end = i, i
+			continue
+		}
+		if comment.End().Line < line-1 {
+			break
+		}
+		start, line = i, comment.Pos().Line
+	}
+
+	texts := []string{}
+	for _, comment := range leading[start:end] {
+		texts = append(texts, comment.Text())
+	}
+
+	return strings.Join(texts, "\n")
+}
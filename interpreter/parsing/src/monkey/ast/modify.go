@@ -0,0 +1,223 @@
+package ast
+
+// ModifierFunc returns the node to put in place of the one it is given
+type ModifierFunc func(Node) Node
+
+// Modify returns a copy of the tree rooted at node, rewritten from the bottom up: the children of a
+// node are modified before the node itself is passed to modifier, and every node is replaced by what
+// modifier returns for it. The given tree is left as it is, so the same code can be modified again,
+// as long as modifier returns new nodes instead of changing the ones it is given. A replacement of
+// the wrong kind for its position, e.g. a statement in place of an expression, becomes nil.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+
+	case *Program:
+		modified := *node
+		modified.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&modified)
+
+	case *BlockStatement:
+		modified := *node
+		modified.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&modified)
+
+	case *ExpressionStatement:
+		modified := *node
+		modified.Expression = modifyExpression(node.Expression, modifier)
+		return modifier(&modified)
+
+	case *LetStatement:
+		modified := *node
+		modified.Name = modifyIdentifier(node.Name, modifier)
+		modified.Value = modifyExpression(node.Value, modifier)
+		return modifier(&modified)
+
+	case *ReturnStatement:
+		modified := *node
+		modified.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+		return modifier(&modified)
+
+	case *ThrowStatement:
+		modified := *node
+		modified.Value = modifyExpression(node.Value, modifier)
+		return modifier(&modified)
+
+	case *WhileStatement:
+		modified := *node
+		modified.Condition = modifyExpression(node.Condition, modifier)
+		modified.Body = modifyBlock(node.Body, modifier)
+		return modifier(&modified)
+
+	case *ForStatement:
+		modified := *node
+		modified.Variable = modifyIdentifier(node.Variable, modifier)
+		modified.Iterable = modifyExpression(node.Iterable, modifier)
+		modified.Body = modifyBlock(node.Body, modifier)
+		return modifier(&modified)
+
+	case *PrefixExpression:
+		modified := *node
+		modified.Right = modifyExpression(node.Right, modifier)
+		return modifier(&modified)
+
+	case *InfixExpression:
+		modified := *node
+		modified.Left = modifyExpression(node.Left, modifier)
+		modified.Right = modifyExpression(node.Right, modifier)
+		return modifier(&modified)
+
+	case *AssignExpression:
+		modified := *node
+		modified.Target = modifyExpression(node.Target, modifier)
+		modified.Value = modifyExpression(node.Value, modifier)
+		return modifier(&modified)
+
+	case *IfExpression:
+		modified := *node
+		modified.Condition = modifyExpression(node.Condition, modifier)
+		modified.Consequence = modifyBlock(node.Consequence, modifier)
+		modified.Alternative = modifyBlock(node.Alternative, modifier)
+		return modifier(&modified)
+
+	case *FunctionLiteral:
+		modified := *node
+		modified.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		if node.Defaults != nil {
+			modified.Defaults = make(map[string]Expression, len(node.Defaults))
+			for name, value := range node.Defaults {
+				modified.Defaults[name] = modifyExpression(value, modifier)
+			}
+		}
+		modified.Rest = modifyIdentifier(node.Rest, modifier)
+		modified.Body = modifyBlock(node.Body, modifier)
+		return modifier(&modified)
+
+	case *MacroLiteral:
+		modified := *node
+		modified.Parameters = modifyIdentifiers(node.Parameters, modifier)
+		modified.Body = modifyBlock(node.Body, modifier)
+		return modifier(&modified)
+
+	case *CallExpression:
+		modified := *node
+		modified.Function = modifyExpression(node.Function, modifier)
+		modified.Arguments = modifyExpressions(node.Arguments, modifier)
+		return modifier(&modified)
+
+	case *TemplateLiteral:
+		modified := *node
+		modified.Parts = modifyExpressions(node.Parts, modifier)
+		return modifier(&modified)
+
+	case *ArrayLiteral:
+		modified := *node
+		modified.Elements = modifyExpressions(node.Elements, modifier)
+		return modifier(&modified)
+
+	case *IndexExpression:
+		modified := *node
+		modified.Left = modifyExpression(node.Left, modifier)
+		modified.Index = modifyExpression(node.Index, modifier)
+		return modifier(&modified)
+
+	case *SliceExpression:
+		modified := *node
+		modified.Left = modifyExpression(node.Left, modifier)
+		modified.Start = modifyExpression(node.Start, modifier)
+		modified.Stop = modifyExpression(node.Stop, modifier)
+		modified.Step = modifyExpression(node.Step, modifier)
+		return modifier(&modified)
+
+	case *HashLiteral:
+		modified := *node
+		if node.Pairs != nil {
+			modified.Pairs = make([]HashPair, len(node.Pairs))
+			for i, pair := range node.Pairs {
+				modified.Pairs[i] = HashPair{
+					Key:   modifyExpression(pair.Key, modifier),
+					Value: modifyExpression(pair.Value, modifier),
+				}
+			}
+		}
+		return modifier(&modified)
+
+	case *TryExpression:
+		modified := *node
+		modified.Block = modifyBlock(node.Block, modifier)
+		modified.CatchParam = modifyIdentifier(node.CatchParam, modifier)
+		modified.Catch = modifyBlock(node.Catch, modifier)
+		modified.Finally = modifyBlock(node.Finally, modifier)
+		return modifier(&modified)
+	}
+
+	// nodes without children
+	return modifier(node)
+}
+
+// modifyStatements modifies each statement of a list into a new list
+func modifyStatements(statements []Statement, modifier ModifierFunc) []Statement {
+	if statements == nil {
+		return nil
+	}
+
+	modified := make([]Statement, len(statements))
+	for i, statement := range statements {
+		if statement != nil {
+			modified[i], _ = Modify(statement, modifier).(Statement)
+		}
+	}
+	return modified
+}
+
+// modifyExpression modifies an optional expression, leaving it nil if it is missing
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	if exp == nil {
+		return nil
+	}
+	modified, _ := Modify(exp, modifier).(Expression)
+	return modified
+}
+
+// modifyExpressions modifies each expression of a list into a new list
+func modifyExpressions(exps []Expression, modifier ModifierFunc) []Expression {
+	if exps == nil {
+		return nil
+	}
+
+	modified := make([]Expression, len(exps))
+	for i, exp := range exps {
+		modified[i] = modifyExpression(exp, modifier)
+	}
+	return modified
+}
+
+// modifyBlock modifies an optional block, such as a missing else branch
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	modified, _ := Modify(block, modifier).(*BlockStatement)
+	return modified
+}
+
+// modifyIdentifier modifies an optional identifier, such as a missing rest parameter
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	modified, _ := Modify(ident, modifier).(*Identifier)
+	return modified
+}
+
+// modifyIdentifiers modifies each identifier of a parameter list into a new list
+func modifyIdentifiers(idents []*Identifier, modifier ModifierFunc) []*Identifier {
+	if idents == nil {
+		return nil
+	}
+
+	modified := make([]*Identifier, len(idents))
+	for i, ident := range idents {
+		modified[i] = modifyIdentifier(ident, modifier)
+	}
+	return modified
+}
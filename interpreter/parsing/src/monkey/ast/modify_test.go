@@ -0,0 +1,169 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression {
+		return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1}
+	}
+	two := func() Expression {
+		return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2}
+	}
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	}
+	block := func(exp Expression) *BlockStatement {
+		return &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: exp}}}
+	}
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		return two()
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&InfixExpression{Left: one(), Operator: "+", Right: two()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&InfixExpression{Left: two(), Operator: "+", Right: one()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&AssignExpression{Target: ident("a"), Value: one()},
+			&AssignExpression{Target: ident("a"), Value: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&SliceExpression{Left: one(), Start: one(), Step: one()},
+			&SliceExpression{Left: two(), Start: two(), Step: two()},
+		},
+		{
+			&IfExpression{Condition: one(), Consequence: block(one()), Alternative: block(one())},
+			&IfExpression{Condition: two(), Consequence: block(two()), Alternative: block(two())},
+		},
+		{
+			&IfExpression{Condition: one(), Consequence: block(one())},
+			&IfExpression{Condition: two(), Consequence: block(two())},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&LetStatement{Name: ident("a"), Value: one()},
+			&LetStatement{Name: ident("a"), Value: two()},
+		},
+		{
+			&ThrowStatement{Value: one()},
+			&ThrowStatement{Value: two()},
+		},
+		{
+			&FunctionLiteral{
+				Parameters: []*Identifier{ident("a"), ident("b")},
+				Defaults:   map[string]Expression{"b": one()},
+				Body:       block(one()),
+			},
+			&FunctionLiteral{
+				Parameters: []*Identifier{ident("a"), ident("b")},
+				Defaults:   map[string]Expression{"b": two()},
+				Body:       block(two()),
+			},
+		},
+		{
+			&MacroLiteral{Parameters: []*Identifier{ident("a")}, Body: block(one())},
+			&MacroLiteral{Parameters: []*Identifier{ident("a")}, Body: block(two())},
+		},
+		{
+			&CallExpression{Function: ident("f"), Arguments: []Expression{one(), one()}},
+			&CallExpression{Function: ident("f"), Arguments: []Expression{two(), two()}},
+		},
+		{
+			&ArrayLiteral{Elements: []Expression{one(), one()}},
+			&ArrayLiteral{Elements: []Expression{two(), two()}},
+		},
+		{
+			&TemplateLiteral{Parts: []Expression{one()}},
+			&TemplateLiteral{Parts: []Expression{two()}},
+		},
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}}},
+			&HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}}},
+		},
+		{
+			&TryExpression{Block: block(one()), CatchParam: ident("e"), Catch: block(one()), Finally: block(one())},
+			&TryExpression{Block: block(two()), CatchParam: ident("e"), Catch: block(two()), Finally: block(two())},
+		},
+		{
+			&WhileStatement{Condition: one(), Body: block(one())},
+			&WhileStatement{Condition: two(), Body: block(two())},
+		},
+		{
+			&ForStatement{Variable: ident("x"), Iterable: one(), Body: block(one())},
+			&ForStatement{Variable: ident("x"), Iterable: two(), Body: block(two())},
+		},
+	}
+
+	for i, tt := range tests {
+		original := tt.input.String()
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("test[%d] not equal. got=%#v, want=%#v", i, modified, tt.expected)
+		}
+		if tt.input.String() != original {
+			t.Errorf("test[%d] input was changed. got=%q, want=%q", i, tt.input.String(), original)
+		}
+	}
+}
+
+func TestModifyIsBottomUp(t *testing.T) {
+	// (1 + 2) with the infix replaced by its own String, which must already see both children as 3
+	three := func(node Node) Node {
+		if _, ok := node.(*IntegerLiteral); ok {
+			return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "3"}, Value: 3}
+		}
+		if infix, ok := node.(*InfixExpression); ok {
+			return &StringLiteral{Token: token.Token{Type: token.STRING, Literal: infix.String()}, Value: infix.String()}
+		}
+		return node
+	}
+
+	input := &InfixExpression{
+		Left:     &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+		Operator: "+",
+		Right:    &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2},
+	}
+
+	modified, ok := Modify(input, three).(*StringLiteral)
+	if !ok {
+		t.Fatalf("modified is not *StringLiteral. got=%T", modified)
+	}
+	if modified.Value != "(3 + 3)" {
+		t.Errorf("modified has wrong value. got=%q", modified.Value)
+	}
+}
@@ -0,0 +1,166 @@
+package ast
+
+// A Visitor's Visit method is called by Walk for every node it reaches. If the visitor w it
+// returns is not nil, Walk visits each child of the node with w, then calls w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node depth-first, visiting the children of a node in source
+// order. Missing optional children, such as an absent else branch, are skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch node := node.(type) {
+
+	case *Program:
+		walkStatements(v, node.Statements)
+
+	case *BlockStatement:
+		walkStatements(v, node.Statements)
+
+	case *ExpressionStatement:
+		walkExpression(v, node.Expression)
+
+	case *LetStatement:
+		walkIdentifier(v, node.Name)
+		walkExpression(v, node.Value)
+
+	case *ReturnStatement:
+		walkExpression(v, node.ReturnValue)
+
+	case *ThrowStatement:
+		walkExpression(v, node.Value)
+
+	case *WhileStatement:
+		walkExpression(v, node.Condition)
+		walkBlock(v, node.Body)
+
+	case *ForStatement:
+		walkIdentifier(v, node.Variable)
+		walkExpression(v, node.Iterable)
+		walkBlock(v, node.Body)
+
+	case *PrefixExpression:
+		walkExpression(v, node.Right)
+
+	case *InfixExpression:
+		walkExpression(v, node.Left)
+		walkExpression(v, node.Right)
+
+	case *AssignExpression:
+		walkExpression(v, node.Target)
+		walkExpression(v, node.Value)
+
+	case *IfExpression:
+		walkExpression(v, node.Condition)
+		walkBlock(v, node.Consequence)
+		walkBlock(v, node.Alternative)
+
+	case *FunctionLiteral:
+		// each default value follows its parameter, as it is written
+		for _, param := range node.Parameters {
+			walkIdentifier(v, param)
+			if param != nil {
+				walkExpression(v, node.Defaults[param.Value])
+			}
+		}
+		walkIdentifier(v, node.Rest)
+		walkBlock(v, node.Body)
+
+	case *MacroLiteral:
+		for _, param := range node.Parameters {
+			walkIdentifier(v, param)
+		}
+		walkBlock(v, node.Body)
+
+	case *CallExpression:
+		walkExpression(v, node.Function)
+		walkExpressions(v, node.Arguments)
+
+	case *TemplateLiteral:
+		walkExpressions(v, node.Parts)
+
+	case *ArrayLiteral:
+		walkExpressions(v, node.Elements)
+
+	case *IndexExpression:
+		walkExpression(v, node.Left)
+		walkExpression(v, node.Index)
+
+	case *SliceExpression:
+		walkExpression(v, node.Left)
+		walkExpression(v, node.Start)
+		walkExpression(v, node.Stop)
+		walkExpression(v, node.Step)
+
+	case *HashLiteral:
+		for _, pair := range node.Pairs {
+			walkExpression(v, pair.Key)
+			walkExpression(v, pair.Value)
+		}
+
+	case *TryExpression:
+		walkBlock(v, node.Block)
+		walkIdentifier(v, node.CatchParam)
+		walkBlock(v, node.Catch)
+		walkBlock(v, node.Finally)
+	}
+
+	v.Visit(nil)
+}
+
+// inspector adapts a function to the Visitor interface
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node like Walk, calling f for each node. The children of
+// a node are only inspected when f returns true for it, and f(nil) follows its last child.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// walkStatements walks each statement of a list, skipping ones that failed to parse
+func walkStatements(v Visitor, statements []Statement) {
+	for _, statement := range statements {
+		if statement != nil {
+			Walk(v, statement)
+		}
+	}
+}
+
+// walkExpression walks an optional expression
+func walkExpression(v Visitor, exp Expression) {
+	if exp != nil {
+		Walk(v, exp)
+	}
+}
+
+// walkExpressions walks each expression of a list
+func walkExpressions(v Visitor, exps []Expression) {
+	for _, exp := range exps {
+		walkExpression(v, exp)
+	}
+}
+
+// walkBlock walks an optional block, such as a missing else branch
+func walkBlock(v Visitor, block *BlockStatement) {
+	if block != nil {
+		Walk(v, block)
+	}
+}
+
+// walkIdentifier walks an optional identifier, such as a missing rest parameter
+func walkIdentifier(v Visitor, ident *Identifier) {
+	if ident != nil {
+		Walk(v, ident)
+	}
+}
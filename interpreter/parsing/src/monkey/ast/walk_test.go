@@ -0,0 +1,103 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+)
+
+func TestInspect(t *testing.T) {
+	integer := func(value int64) *IntegerLiteral {
+		return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: fmt.Sprintf("%d", value)}, Value: value}
+	}
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	}
+	block := func(exp Expression) *BlockStatement {
+		return &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: exp}}}
+	}
+
+	tests := []struct {
+		input    Node
+		expected []string // the visited identifiers and integers, in order
+	}{
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: ident("a"), Value: integer(1)}, {Key: ident("b"), Value: integer(2)}}},
+			[]string{"a", "1", "b", "2"},
+		},
+		{
+			&IfExpression{Condition: ident("c"), Consequence: block(integer(1)), Alternative: block(integer(2))},
+			[]string{"c", "1", "2"},
+		},
+		{
+			&IfExpression{Condition: ident("c"), Consequence: block(integer(1))},
+			[]string{"c", "1"},
+		},
+		{
+			&FunctionLiteral{
+				Parameters: []*Identifier{ident("a"), ident("b"), ident("c")},
+				Defaults:   map[string]Expression{"b": integer(1), "c": integer(2)},
+				Rest:       ident("rest"),
+				Body:       block(ident("a")),
+			},
+			[]string{"a", "b", "1", "c", "2", "rest", "a"},
+		},
+		{
+			&LetStatement{Name: ident("x"), Value: &InfixExpression{Left: integer(1), Operator: "+", Right: integer(2)}},
+			[]string{"x", "1", "2"},
+		},
+		{
+			&CallExpression{Function: ident("f"), Arguments: []Expression{integer(1), &ArrayLiteral{Elements: []Expression{integer(2)}}}},
+			[]string{"f", "1", "2"},
+		},
+		{
+			&SliceExpression{Left: ident("a"), Stop: integer(1), Step: integer(2)},
+			[]string{"a", "1", "2"},
+		},
+		{
+			&TryExpression{Block: block(integer(1)), CatchParam: ident("e"), Catch: block(ident("e")), Finally: block(integer(2))},
+			[]string{"1", "e", "e", "2"},
+		},
+		{
+			&ForStatement{Variable: ident("x"), Iterable: ident("xs"), Body: block(&AssignExpression{Target: ident("y"), Value: ident("x")})},
+			[]string{"x", "xs", "y", "x"},
+		},
+	}
+
+	for i, tt := range tests {
+		visited := []string{}
+		Inspect(tt.input, func(node Node) bool {
+			switch node := node.(type) {
+			case *Identifier:
+				visited = append(visited, node.Value)
+			case *IntegerLiteral:
+				visited = append(visited, node.String())
+			}
+			return true
+		})
+
+		if !reflect.DeepEqual(visited, tt.expected) {
+			t.Errorf("test[%d] wrong nodes visited. want=%q, got=%q", i, tt.expected, visited)
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	one := &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1}
+	inner := &PrefixExpression{Operator: "-", Right: one}
+	outer := &PrefixExpression{Operator: "!", Right: inner}
+
+	visited := []Node{}
+	Inspect(outer, func(node Node) bool {
+		visited = append(visited, node)
+		return node != inner
+	})
+
+	// the children of inner are skipped, and only outer is followed by nil
+	expected := []Node{outer, inner, nil}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong nodes visited. want=%v, got=%v", expected, visited)
+	}
+}
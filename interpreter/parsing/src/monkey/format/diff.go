@@ -0,0 +1,132 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// context is the number of unchanged lines shown around the changes of a diff
+const context = 3
+
+// edit is a line kept (' '), removed ('-') or added ('+') by a diff, along with the indices of
+// the lines before it in the old and new text
+type edit struct {
+	op       byte
+	line     string
+	old, new int
+}
+
+// Diff returns the changes from old to new in unified diff format, or nil if they are equal
+func Diff(oldName string, old []byte, newName string, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	edits := diffLines(splitLines(old), splitLines(new))
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// changes closer together than twice the context share a hunk
+		last := i
+		for j := i + 1; j < len(edits) && j-last-1 <= 2*context; j++ {
+			if edits[j].op != ' ' {
+				last = j
+			}
+		}
+
+		start := max(i-context, 0)
+		end := min(last+context+1, len(edits))
+		writeHunk(&out, edits[start:end])
+		i = end
+	}
+
+	return out.Bytes()
+}
+
+// writeHunk writes a run of edits with its @@ header
+func writeHunk(out *bytes.Buffer, edits []edit) {
+	oldLines, newLines := 0, 0
+	for _, e := range edits {
+		if e.op != '+' {
+			oldLines++
+		}
+		if e.op != '-' {
+			newLines++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[0].old, oldLines), hunkRange(edits[0].new, newLines))
+	for _, e := range edits {
+		out.WriteByte(e.op)
+		out.WriteString(e.line)
+		if len(e.line) == 0 || e.line[len(e.line)-1] != '\n' {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the lines of a hunk starting after the first index lines, an empty range
+// is given by the line before it
+func hunkRange(index, lines int) string {
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", index)
+	}
+	return fmt.Sprintf("%d,%d", index+1, lines)
+}
+
+// splitLines splits text after each newline, the last line lacks one if text does not end with it
+func splitLines(text []byte) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := bytes.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		lines = append(lines, string(text[:i]))
+		text = text[i:]
+	}
+	return lines
+}
+
+// diffLines returns the edits turning a into b, keeping their longest common subsequence of lines
+func diffLines(a, b []string) []edit {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case j == len(b) || i < len(a) && common[i+1][j] >= common[i][j+1]:
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	return edits
+}
@@ -0,0 +1,615 @@
+// Package format prints Monkey source code in its canonical style.
+//
+// Statements go on their own lines, indented by four spaces per block, and end with a semicolon
+// unless they end with a block. Operators are surrounded by spaces and parentheses are only kept
+// where the parser's precedence table needs them. Blocks, arrays and hashes written on a single
+// line stay on one line. Comments are kept next to the statements or entries they were written at,
+// as are single blank lines between statements.
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/token"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/ast"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
+)
+
+// indentation is written once for every block enclosing a line
+const indentation = "    "
+
+// ParseError lists the syntax errors that kept a source from being formatted
+type ParseError struct {
+	Errors []*parser.ParseError
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Source returns src formatted in the canonical style, or a *ParseError if it does not parse.
+// The filename is only used to report the position of syntax errors.
+func Source(filename string, src []byte) ([]byte, error) {
+	p := parser.New(lexer.NewFile(filename, string(src)))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.Errors()}
+	}
+
+	pr := &printer{src: src}
+	pr.statements(program.Statements, program.Dangling)
+	if pr.out.Len() > 0 {
+		pr.out.WriteString("\n")
+	}
+
+	return pr.out.Bytes(), nil
+}
+
+// printer writes the canonical form of a program parsed from src
+type printer struct {
+	src    []byte
+	out    bytes.Buffer
+	indent int
+
+	comments []*ast.Comment // comments from inside the statement being printed, not placed yet
+}
+
+// newline starts a new line at the current indentation
+func (p *printer) newline() {
+	p.out.WriteString("\n" + strings.Repeat(indentation, p.indent))
+}
+
+// line starts the next line of a list of statements or comments, keeping one blank line where
+// the source had any between the line ending on prev and the one starting on next
+func (p *printer) line(prev, next int) {
+	if p.out.Len() == 0 {
+		return
+	}
+	if prev > 0 && next-prev > 1 {
+		p.out.WriteString("\n")
+	}
+	p.newline()
+}
+
+// statements prints a list of statements, followed by the comments after the last one
+func (p *printer) statements(statements []ast.Statement, dangling []*ast.Comment) {
+	prev := 0
+
+	for i, stmt := range statements {
+		trivia := stmt.Comments()
+
+		var interior []*ast.Comment
+		for _, comment := range trivia.Leading {
+			if comment.Pos().Offset >= stmt.Pos().Offset {
+				interior = append(interior, comment)
+				continue
+			}
+			p.line(prev, comment.Pos().Line)
+			p.out.WriteString(comment.String())
+			prev = comment.End().Line
+		}
+
+		p.line(prev, stmt.Pos().Line)
+
+		// an if or try ends with a block, a semicolon only keeps the next statement from continuing it
+		terminate := !endsWithBlock(stmt) || i < len(statements)-1 && continuesExpression(statements[i+1])
+
+		outer := p.comments
+		p.comments = interior
+		p.statement(stmt, terminate)
+		leftover := p.comments
+		p.comments = outer
+
+		prev = stmt.End().Line
+		if last := p.trailing(append(leftover, trivia.Trailing...)); last > prev {
+			prev = last
+		}
+	}
+
+	for _, comment := range dangling {
+		p.line(prev, comment.Pos().Line)
+		p.out.WriteString(comment.String())
+		prev = comment.End().Line
+	}
+}
+
+// trailing prints comments after what is on the current line, and returns the last line they end on
+func (p *printer) trailing(comments []*ast.Comment) int {
+	last := 0
+	lineComment := false
+
+	for _, comment := range comments {
+		// nothing can follow a // comment on its line, the comments after it go on lines of their own
+		if lineComment {
+			p.newline()
+		} else {
+			p.out.WriteString(" ")
+		}
+		p.out.WriteString(comment.String())
+
+		lineComment = lineComment || !comment.IsBlock()
+		last = comment.End().Line
+	}
+
+	return last
+}
+
+// takeComments removes and returns the pending comments that start before pos
+func (p *printer) takeComments(pos token.Position) []*ast.Comment {
+	i := 0
+	for i < len(p.comments) && p.comments[i].Pos().Offset < pos.Offset {
+		i++
+	}
+
+	taken := p.comments[:i:i]
+	p.comments = p.comments[i:]
+
+	return taken
+}
+
+// statement prints stmt, ending it with a semicolon if terminate is set and it doesn't end with a block
+func (p *printer) statement(stmt ast.Statement, terminate bool) {
+	switch stmt := stmt.(type) {
+
+	case *ast.LetStatement:
+		p.out.WriteString("let " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value)
+
+	case *ast.ReturnStatement:
+		p.out.WriteString("return")
+		if stmt.ReturnValue != nil {
+			p.out.WriteString(" ")
+			p.expression(stmt.ReturnValue)
+		}
+
+	case *ast.ThrowStatement:
+		p.out.WriteString("throw ")
+		p.expression(stmt.Value)
+
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression)
+
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		p.out.WriteString(stmt.TokenLiteral())
+
+	case *ast.WhileStatement:
+		p.out.WriteString("while (")
+		p.expression(stmt.Condition)
+		p.out.WriteString(") ")
+		p.block(stmt.Body)
+		return
+
+	case *ast.ForStatement:
+		p.out.WriteString("for (" + stmt.Variable.Value + " in ")
+		p.expression(stmt.Iterable)
+		p.out.WriteString(") ")
+		p.block(stmt.Body)
+		return
+	}
+
+	if terminate {
+		p.out.WriteString(";")
+	}
+}
+
+// block prints a block on a single line if it was written on one and holds at most one statement,
+// and with each statement on its own line otherwise
+func (p *printer) block(block *ast.BlockStatement) {
+	if singleLine(block) {
+		if len(block.Statements) == 0 {
+			p.out.WriteString("{}")
+			return
+		}
+		p.out.WriteString("{ ")
+		p.statement(block.Statements[0], false)
+		p.out.WriteString(" }")
+		return
+	}
+
+	p.out.WriteString("{")
+	p.indent++
+	outer := p.comments
+	p.comments = nil
+	p.statements(block.Statements, block.Dangling)
+	p.comments = outer
+	p.indent--
+	p.newline()
+	p.out.WriteString("}")
+}
+
+// singleLine reports whether block was written on one line, with at most one statement, no comments
+// and only blocks that fit on a single line as well
+func singleLine(block *ast.BlockStatement) bool {
+	if len(block.Statements) > 1 || block.Token.Pos.Line != block.Rbrace.Pos.Line || len(block.Dangling) > 0 {
+		return false
+	}
+
+	fits := true
+	ast.Inspect(block, func(node ast.Node) bool {
+		if !fits {
+			return false
+		}
+
+		switch node := node.(type) {
+		case *ast.BlockStatement:
+			fits = node == block || singleLine(node)
+		case ast.Statement:
+			trivia := node.Comments()
+			fits = len(trivia.Leading) == 0 && len(trivia.Trailing) == 0
+		}
+		return fits
+	})
+
+	return fits
+}
+
+// expression prints exp without parentheses around it
+func (p *printer) expression(exp ast.Expression) {
+	switch exp := exp.(type) {
+
+	case *ast.Identifier:
+		p.out.WriteString(exp.Value)
+
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean, *ast.NullLiteral:
+		p.out.WriteString(exp.TokenLiteral())
+
+	case *ast.StringLiteral:
+		p.out.WriteString(p.source(exp.Token))
+
+	case *ast.TemplateLiteral:
+		// the text parts are printed as written, with the quotes and the ${ } around the expressions
+		for i, part := range exp.Parts {
+			if i%2 == 0 {
+				p.out.WriteString(p.source(part.(*ast.StringLiteral).Token))
+			} else {
+				p.expression(part)
+			}
+		}
+
+	case *ast.PrefixExpression:
+		p.out.WriteString(exp.Operator)
+		p.operand(exp.Right, operandNeedsParens(exp, exp.Right))
+
+	case *ast.InfixExpression:
+		p.operand(exp.Left, leftNeedsParens(exp, exp.Left))
+		p.out.WriteString(" " + exp.Operator + " ")
+		p.operand(exp.Right, rightNeedsParens(exp, exp.Right))
+
+	case *ast.AssignExpression:
+		p.expression(exp.Target)
+		p.out.WriteString(" " + exp.Operator + " ")
+		p.expression(exp.Value)
+
+	case *ast.IfExpression:
+		p.out.WriteString("if (")
+		p.expression(exp.Condition)
+		p.out.WriteString(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.out.WriteString(" else ")
+			p.block(exp.Alternative)
+		}
+
+	case *ast.FunctionLiteral:
+		p.out.WriteString("fn(")
+		for i, param := range exp.Parameters {
+			if i > 0 {
+				p.out.WriteString(", ")
+			}
+			p.out.WriteString(param.Value)
+			if def, ok := exp.Defaults[param.Value]; ok {
+				p.out.WriteString(" = ")
+				p.expression(def)
+			}
+		}
+		if exp.Rest != nil {
+			if len(exp.Parameters) > 0 {
+				p.out.WriteString(", ")
+			}
+			p.out.WriteString("..." + exp.Rest.Value)
+		}
+		p.out.WriteString(") ")
+		p.block(exp.Body)
+
+	case *ast.MacroLiteral:
+		params := make([]string, len(exp.Parameters))
+		for i, param := range exp.Parameters {
+			params[i] = param.Value
+		}
+		p.out.WriteString("macro(" + strings.Join(params, ", ") + ") ")
+		p.block(exp.Body)
+
+	case *ast.CallExpression:
+		p.operand(exp.Function, postfixNeedsParens(exp.Function))
+		if exp.Optional {
+			p.out.WriteString("?.")
+		}
+		p.out.WriteString("(")
+		p.expressions(exp.Arguments)
+		p.out.WriteString(")")
+
+	case *ast.IndexExpression:
+		p.operand(exp.Left, postfixNeedsParens(exp.Left))
+		if exp.Optional {
+			p.out.WriteString("?.")
+		}
+		p.out.WriteString("[")
+		p.expression(exp.Index)
+		p.out.WriteString("]")
+
+	case *ast.SliceExpression:
+		p.operand(exp.Left, postfixNeedsParens(exp.Left))
+		if exp.Optional {
+			p.out.WriteString("?.")
+		}
+		p.out.WriteString("[")
+		p.optional(exp.Start)
+		p.out.WriteString(":")
+		p.optional(exp.Stop)
+		if exp.Step != nil {
+			p.out.WriteString(":")
+			p.expression(exp.Step)
+		}
+		p.out.WriteString("]")
+
+	case *ast.ArrayLiteral:
+		if len(exp.Elements) == 0 || exp.Elements[0].Pos().Line == exp.Token.Pos.Line {
+			p.out.WriteString("[")
+			p.expressions(exp.Elements)
+			p.out.WriteString("]")
+			return
+		}
+
+		p.out.WriteString("[")
+		p.entries(len(exp.Elements), func(i int) (ast.Node, ast.Node) {
+			return exp.Elements[i], exp.Elements[i]
+		}, func(i int) {
+			p.expression(exp.Elements[i])
+		}, exp.Rbracket.Pos)
+		p.out.WriteString("]")
+
+	case *ast.HashLiteral:
+		pair := func(i int) {
+			p.expression(exp.Pairs[i].Key)
+			p.out.WriteString(": ")
+			p.expression(exp.Pairs[i].Value)
+		}
+
+		if len(exp.Pairs) == 0 || exp.Pairs[0].Key.Pos().Line == exp.Token.Pos.Line {
+			p.out.WriteString("{")
+			for i := range exp.Pairs {
+				if i > 0 {
+					p.out.WriteString(", ")
+				}
+				pair(i)
+			}
+			p.out.WriteString("}")
+			return
+		}
+
+		p.out.WriteString("{")
+		p.entries(len(exp.Pairs), func(i int) (ast.Node, ast.Node) {
+			return exp.Pairs[i].Key, exp.Pairs[i].Value
+		}, pair, exp.Rbrace.Pos)
+		p.out.WriteString("}")
+
+	case *ast.TryExpression:
+		p.out.WriteString("try ")
+		p.block(exp.Block)
+		if exp.Catch != nil {
+			p.out.WriteString(" catch ")
+			if exp.CatchParam != nil {
+				p.out.WriteString("(" + exp.CatchParam.Value + ") ")
+			}
+			p.block(exp.Catch)
+		}
+		if exp.Finally != nil {
+			p.out.WriteString(" finally ")
+			p.block(exp.Finally)
+		}
+	}
+}
+
+// operand prints exp, in parentheses if parens is set
+func (p *printer) operand(exp ast.Expression, parens bool) {
+	if parens {
+		p.out.WriteString("(")
+	}
+	p.expression(exp)
+	if parens {
+		p.out.WriteString(")")
+	}
+}
+
+// optional prints exp unless it was left out, like the bounds of a slice
+func (p *printer) optional(exp ast.Expression) {
+	if exp != nil {
+		p.expression(exp)
+	}
+}
+
+// expressions prints a comma separated list on the current line
+func (p *printer) expressions(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.out.WriteString(", ")
+		}
+		p.expression(exp)
+	}
+}
+
+// entries prints the n entries of an array or hash one per line, along with the comments written
+// between them. span returns the first and last node of an entry, and closing is the position of
+// the bracket closing the list.
+func (p *printer) entries(n int, span func(int) (ast.Node, ast.Node), entry func(int), closing token.Position) {
+	p.indent++
+
+	for i := 0; i < n; i++ {
+		first, last := span(i)
+		for _, comment := range p.takeComments(first.Pos()) {
+			p.newline()
+			p.out.WriteString(comment.String())
+		}
+
+		p.newline()
+		entry(i)
+		if i < n-1 {
+			p.out.WriteString(",")
+		}
+
+		next := closing
+		if i < n-1 {
+			nextFirst, _ := span(i + 1)
+			next = nextFirst.Pos()
+		}
+
+		// comments on the line where the entry ends stay there, the others go above the next one
+		end := 0
+		for end < len(p.comments) && p.comments[end].Pos().Offset < next.Offset &&
+			p.comments[end].Pos().Line == last.End().Line {
+			end++
+		}
+		p.trailing(p.comments[:end])
+		p.comments = p.comments[end:]
+	}
+
+	for _, comment := range p.takeComments(closing) {
+		p.newline()
+		p.out.WriteString(comment.String())
+	}
+
+	p.indent--
+	p.newline()
+}
+
+// source returns the text of tok as it is written in the source, e.g. with its escape sequences
+func (p *printer) source(tok token.Token) string {
+	return string(p.src[tok.Pos.Offset:tok.End.Offset])
+}
+
+// postfix is the precedence of calls and indexing, and highest that of the expressions which can't
+// be split up by an operator next to them, like literals
+const (
+	postfix = parser.INDEX
+	highest = postfix + 1
+)
+
+// precedence returns how tightly the parts of exp are bound together, which decides whether it
+// needs parentheses as the operand of another expression
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(exp.Token.Type)
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression, *ast.IndexExpression, *ast.SliceExpression:
+		return postfix
+	default:
+		return highest
+	}
+}
+
+// leftNeedsParens reports whether the left operand of infix has to be parenthesized.
+// ** is right associative, so it parenthesizes a ** on its left instead of its right.
+func leftNeedsParens(infix *ast.InfixExpression, left ast.Expression) bool {
+	prec := parser.Precedence(infix.Token.Type)
+	if infix.Token.Type == token.POWER {
+		return precedence(left) <= prec
+	}
+	return precedence(left) < prec
+}
+
+// rightNeedsParens reports whether the right operand of infix has to be parenthesized.
+// A prefix expression never does, the parser reads one wherever an operand starts.
+func rightNeedsParens(infix *ast.InfixExpression, right ast.Expression) bool {
+	if _, ok := right.(*ast.PrefixExpression); ok {
+		return false
+	}
+
+	prec := parser.Precedence(infix.Token.Type)
+	if infix.Token.Type == token.POWER {
+		return precedence(right) < prec
+	}
+	return precedence(right) <= prec
+}
+
+// operandNeedsParens reports whether the operand of prefix has to be parenthesized. A negation of
+// a negation is, so that it doesn't read as --.
+func operandNeedsParens(prefix *ast.PrefixExpression, operand ast.Expression) bool {
+	if inner, ok := operand.(*ast.PrefixExpression); ok {
+		return prefix.Operator == "-" && inner.Operator == "-"
+	}
+	return precedence(operand) < parser.PREFIX
+}
+
+// postfixNeedsParens reports whether exp has to be parenthesized to be called or indexed
+func postfixNeedsParens(exp ast.Expression) bool {
+	return precedence(exp) < parser.CALL
+}
+
+// endsWithBlock reports whether stmt is an if or try expression, which need no semicolon after their block
+func endsWithBlock(stmt ast.Statement) bool {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	switch es.Expression.(type) {
+	case *ast.IfExpression, *ast.TryExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+// continuesExpression reports whether stmt starts with a token the parser would read as an operator
+// applied to the expression before it, when that expression is not ended by a semicolon
+func continuesExpression(stmt ast.Statement) bool {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	return parser.Precedence(startToken(es.Expression)) > parser.LOWEST
+}
+
+// startToken returns the type of the first token exp is printed with
+func startToken(exp ast.Expression) token.TokenType {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		if leftNeedsParens(exp, exp.Left) {
+			return token.LPAREN
+		}
+		return startToken(exp.Left)
+	case *ast.AssignExpression:
+		return startToken(exp.Target)
+	case *ast.CallExpression:
+		return postfixStartToken(exp.Function)
+	case *ast.IndexExpression:
+		return postfixStartToken(exp.Left)
+	case *ast.SliceExpression:
+		return postfixStartToken(exp.Left)
+	case *ast.PrefixExpression:
+		return exp.Token.Type
+	case *ast.ArrayLiteral:
+		return token.LBRACKET
+	default:
+		return token.ILLEGAL
+	}
+}
+
+// postfixStartToken returns the type of the first token of a call or index applied to exp
+func postfixStartToken(exp ast.Expression) token.TokenType {
+	if postfixNeedsParens(exp) {
+		return token.LPAREN
+	}
+	return startToken(exp)
+}
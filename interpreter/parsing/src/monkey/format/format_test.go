@@ -0,0 +1,210 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
+	"github.com/anirudhlakkaraju/go-interpreter/interpreter/parsing/src/monkey/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let  x=5", "let x = 5;\n"},
+		{"", ""},
+		{"x;y;", "x;\ny;\n"},
+		// parentheses are only kept where the precedence table needs them
+		{"((1 + 2)) * 3 - (4 - 5) + (6 - 7) - 8", "(1 + 2) * 3 - (4 - 5) + (6 - 7) - 8;\n"},
+		{"(2 ** 3) ** 2 + 2 ** (3 ** 2)", "(2 ** 3) ** 2 + 2 ** 3 ** 2;\n"},
+		{"-(a + b) + (-a)[0] + -(a[0]) + 2 ** -1 - (-(-1))", "-(a + b) + (-a)[0] + -a[0] + 2 ** -1 - -(-1);\n"},
+		{"!(a && b) || (c || d) && e", "!(a && b) || (c || d) && e;\n"},
+		{"(a ?? b) ?? c; a ?? (b ?? c)", "a ?? b ?? c;\na ?? (b ?? c);\n"},
+		{"x = (y = 1); x + (y += 1)", "x = y = 1;\nx + (y += 1);\n"},
+		{"(f(x))(y)[0]; (a + b)(1); (f)?.[1:]", "f(x)(y)[0];\n(a + b)(1);\nf?.[1:];\n"},
+		{"a[ : : -1]; a[1:2:3]; a[:2]", "a[::-1];\na[1:2:3];\na[:2];\n"},
+		{"let f = fn(a,b=2,...rest){a}", "let f = fn(a, b = 2, ...rest) { a };\n"},
+		{"let m = macro(a,b){quote(unquote(a))}", "let m = macro(a, b) { quote(unquote(a)) };\n"},
+		// literals are printed as they were written
+		{`"a\n${ x+1 }\u{1F600}" + ` + "`raw\nstring`" + ` + 1e3`, `"a\n${x + 1}\u{1F600}" + ` + "`raw\nstring`" + " + 1e3;\n"},
+		{`{"a":1,"b":[1,2]}; {}; []`, "{\"a\": 1, \"b\": [1, 2]};\n{};\n[];\n"},
+		// blocks written over several lines are indented, single line ones stay
+		{
+			"let f = fn(x) {\nlet y = x * 2\n  y }",
+			"let f = fn(x) {\n    let y = x * 2;\n    y;\n};\n",
+		},
+		{
+			"if (x) { y } else { if (z) { w; v } }",
+			"if (x) { y } else {\n    if (z) {\n        w;\n        v;\n    }\n}\n",
+		},
+		{
+			"while (x < 10) { x += 1 }\nfor (c in s) {\nif (c) { break } else { continue }\n}",
+			"while (x < 10) { x += 1 }\nfor (c in s) {\n    if (c) { break } else { continue }\n}\n",
+		},
+		{
+			"try { a } catch { b }; try {\nc\n} catch (e) { e } finally {}",
+			"try { a } catch { b }\ntry {\n    c;\n} catch (e) { e } finally {}\n",
+		},
+		// an if only needs a semicolon when the next statement would continue it
+		{"if (x) { 1 }; [1]; if (y) { 2 }; -1; if (z) { 3 }; f()", "if (x) { 1 };\n[1];\nif (y) { 2 };\n-1;\nif (z) { 3 }\nf();\n"},
+		// arrays and hashes starting their entries on a new line get one line per entry
+		{
+			"let h = {\n\"a\": 1, \"b\": [\n1, 2] }",
+			"let h = {\n    \"a\": 1,\n    \"b\": [\n        1,\n        2\n    ]\n};\n",
+		},
+		// single blank lines between statements are kept
+		{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source("", []byte(tt.input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+		if string(formatted) != tt.expected {
+			t.Errorf("%q: wrong formatting.\ngot:\n%s\nwant:\n%s", tt.input, formatted, tt.expected)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// Section
+
+// add returns the sum of a and b
+let add = fn(a, b) {
+  // the result
+  a + b // sum
+  /* nothing else */
+};   // trailing
+let total = add(1, /* inline */ 2);
+let config = {
+  "port": 80, // default
+  // verbose output
+  "debug": false
+  /* more to come */
+};
+/* end */`
+
+	expected := `// Section
+
+// add returns the sum of a and b
+let add = fn(a, b) {
+    // the result
+    a + b; // sum
+    /* nothing else */
+}; // trailing
+let total = add(1, 2); /* inline */
+let config = {
+    "port": 80, // default
+    // verbose output
+    "debug": false
+    /* more to come */
+};
+/* end */
+`
+
+	formatted, err := Source("", []byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(formatted) != expected {
+		t.Errorf("wrong formatting.\ngot:\n%s\nwant:\n%s", formatted, expected)
+	}
+}
+
+func TestSourceKeepsMeaning(t *testing.T) {
+	inputs := []string{
+		"let n = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } let n = n + x * y; } }; n",
+		"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; map(range(1, 6), fact)",
+		"let h = {\"on\": fn(x) { x + 1 }}; [h[\"on\"]?.(1), h[\"off\"]?.(1), \"abc\"?.[1:], \"abc\"?.[-1]]",
+		"let x = 2; \"x=${x}, half=${x / 4.0}, ${if (x > 1) { \"big\" }}, ${\"nested ${x * 3}\"}\"",
+		"let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) }; unless(10 > 5, puts(\"no\"), puts(\"yes\"));",
+		"try { throw {\"message\": \"x\"} } catch (e) { e[\"message\"] } finally { puts(1) }",
+		"let f = fn(a, b = a * 2, ...rest) { [a, b, rest] }; f(1); x *= x += 1; (fn(x) { x })(1)",
+		"-a ** 2; (-a) ** 2; a - (b - c); a - b - c; !-a; -(-a); 1 - -1",
+		"// one\nlet a = [1, // first\n  2 /* second */\n]; // after\n\n/* doc */ let b = 1",
+	}
+
+	for _, input := range inputs {
+		formatted, err := Source("", []byte(input))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", input, err)
+			continue
+		}
+
+		if parse(t, string(formatted)) != parse(t, input) {
+			t.Errorf("%q: formatting changed the program. got=%q", input, formatted)
+		}
+
+		again, err := Source("", formatted)
+		if err != nil {
+			t.Errorf("%q: formatted source does not parse: %s", input, err)
+			continue
+		}
+		if string(again) != string(formatted) {
+			t.Errorf("%q: formatting is not stable.\nonce:\n%s\ntwice:\n%s", input, formatted, again)
+		}
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	_, err := Source("main.mk", []byte("let x = ;\nlet = 1;"))
+
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("err is not *ParseError. got=%T (%v)", err, err)
+	}
+	if len(parseErr.Errors) != 2 {
+		t.Errorf("wrong number of errors. got=%d", len(parseErr.Errors))
+	}
+
+	expected := "main.mk:1:9: no prefix parse function found for ;\nmain.mk:2:5: expected next token to be IDENT. got = instead"
+	if err.Error() != expected {
+		t.Errorf("wrong error message. got=%q, want=%q", err.Error(), expected)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm"
+
+	expected := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+\ No newline at end of file
+`
+
+	if diff := string(Diff("old", []byte(old), "new", []byte(new))); diff != expected {
+		t.Errorf("wrong diff.\ngot:\n%s\nwant:\n%s", diff, expected)
+	}
+
+	if diff := Diff("old", []byte(old), "new", []byte(old)); diff != nil {
+		t.Errorf("diff of equal texts is not nil. got=%q", diff)
+	}
+}
+
+// parse returns the String of the program parsed from src, which ignores its layout
+func parse(t *testing.T, src string) string {
+	t.Helper()
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser has %d errors: %v", src, len(p.Errors()), p.Errors())
+	}
+
+	return program.String()
+}
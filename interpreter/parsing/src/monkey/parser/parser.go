@@ -13,27 +13,53 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y or x += y
+	NULLISH     // ??
+	OR          // ||
+	AND         // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
+	POWER       // X ** Y
 	CALL        // myFunction(X)
 	INDEX       // myArray[index]
 )
 
 // precedences maps token types to their respective precedence levels
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.NULLISH:         NULLISH,
+	token.OR:              OR,
+	token.AND:             AND,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.POWER:           POWER,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.OPTIONAL:        INDEX,
+}
+
+// Precedence returns the precedence of the infix operator t, LOWEST if t is not one
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
 }
 
 // Function types for prefix and infix parse functions
@@ -48,17 +74,25 @@ type Parser struct {
 
 	curToken  token.Token
 	peekToken token.Token
-	errors    []string
+	errors    []*ParseError
+
+	failures   int // errors reported, including the duplicates left out of errors
+	recovered  int // failures already recovered from by skipping to the next statement
+	braceDepth int // number of '{' opened and not yet closed, up to and including curToken
+
+	comments []*ast.Comment // comments read and not yet attached to a statement, in source order
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	loopDepth int // number of loops enclosing the current token within the current function
 }
 
 // New initializes and returns a new Parser given a lexer
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []*ParseError{},
 	}
 
 	// Read two tokens so curToken and peekToken are both set
@@ -69,17 +103,22 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanExpression)
 	p.registerPrefix(token.FALSE, p.parseBooleanExpression)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_HEAD, p.parseTemplateLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
-	p.registerPrefix(token.COMMENT, p.parseCommentLiteral)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
 
 	// Initialize infix parsing functions for the corresponding token types
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
@@ -91,8 +130,21 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.OPTIONAL, p.parseOptionalExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 
 	return p
 }
@@ -102,15 +154,52 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
-// Errors returns the Parser's slice of errors
-func (p *Parser) Errors() []string {
+// ParseError is a syntax error found at Pos
+type ParseError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Errors returns the syntax errors found in the input, in the order they were found
+func (p *Parser) Errors() []*ParseError {
 	return p.errors
 }
 
-// nextToken advances the Parser's curToken and peekToken by one token
+// errorf records a syntax error at pos. Only the first error at a position is kept, the
+// others are knock-on effects of the same mistake.
+func (p *Parser) errorf(pos token.Position, format string, a ...interface{}) {
+	p.failures++
+
+	for _, err := range p.errors {
+		if err.Pos == pos {
+			return
+		}
+	}
+
+	p.errors = append(p.errors, &ParseError{Pos: pos, Msg: fmt.Sprintf(format, a...)})
+}
+
+// nextToken advances the Parser's curToken and peekToken by one token. Comments are set aside
+// on the way, to be attached to the statements around them.
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	for p.peekTokenIs(token.COMMENT) || p.peekTokenIs(token.BLOCK_COMMENT) {
+		p.comments = append(p.comments, &ast.Comment{Token: p.peekToken})
+		p.peekToken = p.l.NextToken()
+	}
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.braceDepth++
+	case token.RBRACE:
+		p.braceDepth--
+	}
 }
 
 // ParseProgram parses the input Monkey Lang string and returns an AST Program node
@@ -120,16 +209,94 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementWithRecovery(0)
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
+
+		// a stray '}' has no block to close
+		if p.braceDepth < 0 {
+			p.braceDepth = 0
+		}
 		p.nextToken()
 	}
+	program.Dangling = p.takeComments(p.curToken.Pos)
 
 	return program
 }
 
+// statementKeywords are the tokens that can only start a statement
+var statementKeywords = map[token.TokenType]bool{
+	token.LET:      true,
+	token.RETURN:   true,
+	token.THROW:    true,
+	token.WHILE:    true,
+	token.FOR:      true,
+	token.BREAK:    true,
+	token.CONTINUE: true,
+}
+
+// parseStatementWithRecovery parses a statement of a block nested depth braces deep. When the
+// statement has a syntax error, the rest of it is skipped and nil is returned, so that parsing
+// carries on with the next statement instead of reporting errors for the leftover tokens.
+func (p *Parser) parseStatementWithRecovery(depth int) ast.Statement {
+	leading := p.takeComments(p.curToken.Pos)
+
+	stmt := p.parseStatement()
+	if p.failures == p.recovered {
+		p.attachComments(stmt, leading)
+		return stmt
+	}
+
+	p.synchronize(depth)
+	p.recovered = p.failures
+
+	return nil
+}
+
+// attachComments gives stmt the comments written before it and the ones still pending from
+// inside it, which are all leading, and the ones after it on the line where it ends
+func (p *Parser) attachComments(stmt ast.Statement, leading []*ast.Comment) {
+	trivia := stmt.Comments()
+	trivia.Leading = append(leading, p.takeComments(p.curToken.Pos)...)
+
+	end := p.curToken.End
+	for len(p.comments) > 0 && p.comments[0].Pos().Line == end.Line {
+		trivia.Trailing = append(trivia.Trailing, p.comments[0])
+		p.comments = p.comments[1:]
+	}
+}
+
+// takeComments removes and returns the pending comments that start before pos
+func (p *Parser) takeComments(pos token.Position) []*ast.Comment {
+	i := 0
+	for i < len(p.comments) && p.comments[i].Pos().Offset < pos.Offset {
+		i++
+	}
+
+	taken := p.comments[:i:i]
+	p.comments = p.comments[i:]
+
+	return taken
+}
+
+// synchronize skips the rest of a statement that failed to parse in a block nested depth braces deep.
+// It stops on the semicolon ending the statement, before a token that starts the next statement or
+// closes the block, or on the closing brace of the block when the failed statement ran into it.
+func (p *Parser) synchronize(depth int) {
+	for !p.curTokenIs(token.EOF) && p.braceDepth >= depth {
+		if p.braceDepth == depth {
+			if p.curTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+				return
+			}
+			if statementKeywords[p.peekToken.Type] {
+				return
+			}
+		}
+		p.nextToken()
+	}
+}
+
 // parseStatement returns a Statement AST node depending on Parser's curToken type
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
@@ -137,6 +304,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -160,7 +335,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -190,8 +365,7 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 // peekError appends an error to Parser's errors slice when peekToken type does not match expected input token type t
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s. got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.peekToken.Pos, "expected next token to be %s. got %s instead", t, p.peekToken.Type)
 }
 
 // parseReturnStatement parses and returns an AST ReturnStatement node
@@ -202,7 +376,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -221,8 +395,7 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 
 // noPrefixParseFnError appends an error to the parser when no prefix parse function exists for the given token type
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function found for %s", t)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.curToken.Pos, "no prefix parse function found for %s", t)
 }
 
 // parseExpression parses an expression based on the given precedence level, using
@@ -242,7 +415,8 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	leftExp := prefix()
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	// a nil expression failed to parse and has already reported why
+	for leftExp != nil && !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -281,8 +455,22 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("Could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken.Pos, "Could not parse %q as integer", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+// parseFloatLiteral parses and returns an AST FloatLiteral node
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorf(p.curToken.Pos, "Could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
@@ -305,24 +493,21 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	p.nextToken()
 
 	expression.Right = p.parseExpression(PREFIX)
+	if expression.Right == nil {
+		return nil
+	}
 
 	return expression
 }
 
 // peekPrecedence returns the precedence of Parser's peekToken type
 func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
-	}
-	return LOWEST
+	return Precedence(p.peekToken.Type)
 }
 
 // curPrecedence returns the precedence of Parser's curToken type
 func (p *Parser) curPrecendence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
-		return p
-	}
-	return LOWEST
+	return Precedence(p.curToken.Type)
 }
 
 // parseInfixExpression parses and returns an AST InfixExpression node.
@@ -338,12 +523,56 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecendence()
+	// ** is right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if p.curTokenIs(token.POWER) {
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
+	if expression.Right == nil {
+		return nil
+	}
 
 	return expression
 }
 
+// isAssignable reports whether target names a variable or an element that can be assigned to
+func isAssignable(target ast.Expression) bool {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.IndexExpression:
+		return !target.Optional
+	default:
+		return false
+	}
+}
+
+// parseAssignExpression parses and returns an AST AssignExpression node.
+// Assignment is right associative, so a = b = 1 assigns 1 to both.
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	if !isAssignable(target) {
+		p.errorf(target.Pos(), "cannot assign to %s", target.String())
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+// parseNullLiteral parses and returns an AST NullLiteral node
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
 // parseBoolean parses and returns an AST Boolean node
 func (p *Parser) parseBooleanExpression() ast.Expression {
 	return &ast.Boolean{
@@ -403,16 +632,33 @@ func (p *Parser) parseIfExpression() ast.Expression {
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+	depth := p.braceDepth
+
+	// comments before the { belong to the statement around the block
+	outer := p.takeComments(block.Token.Pos)
+	defer func() { p.comments = append(outer, p.comments...) }()
 
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementWithRecovery(depth)
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
+
+		// recovering from an error stopped on the closing brace
+		if p.braceDepth < depth {
+			break
+		}
 		p.nextToken()
 	}
+	block.Dangling = p.takeComments(p.curToken.Pos)
+
+	if p.curTokenIs(token.EOF) {
+		p.errorf(p.curToken.Pos, "expected next token to be %s. got %s instead", token.RBRACE, token.EOF)
+		return block
+	}
+	block.Rbrace = p.curToken
 
 	return block
 }
@@ -425,85 +671,161 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
+	// break and continue can't reach loops outside the function
+	outerLoopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = outerLoopDepth
 
 	return lit
 }
 
-// parseFunctionParameters parses and returns a slice of AST Idenifier nodes as function parameters
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseMacroLiteral parses macro(params) { body }. Macros take a plain list of parameters,
+// without default values or a rest parameter.
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.curToken}
 
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return identifiers
+	if !p.expectPeek(token.LPAREN) {
+		return nil
 	}
 
+	lit.Parameters = []*ast.Identifier{}
+	for !p.peekTokenIs(token.RPAREN) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		lit.Parameters = append(lit.Parameters, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
 	p.nextToken()
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 
-	for p.peekTokenIs(token.COMMA) {
+	outerLoopDepth := p.loopDepth
+	p.loopDepth = 0
+	lit.Body = p.parseBlockStatement()
+	p.loopDepth = outerLoopDepth
 
-		// Advancing twice to move onto COMMA first, then onto the next paramter
-		p.nextToken()
-		p.nextToken()
+	return lit
+}
 
-		ident = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
+// parseFunctionParameters parses the parameter list of lit. Parameters may be given a default
+// value with '= expr' once all required ones are listed, and a final '...rest' parameter collects
+// any remaining arguments. It returns false if the list is malformed.
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return true
 	}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+
+			// the rest parameter has to close the list
+			lit.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			return p.expectPeek(token.RPAREN)
+		}
+
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		lit.Parameters = append(lit.Parameters, ident)
+
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+
+			if lit.Defaults == nil {
+				lit.Defaults = map[string]ast.Expression{}
+			}
+			lit.Defaults[ident.Value] = p.parseExpression(LOWEST)
+		} else if len(lit.Defaults) > 0 {
+			p.errorf(ident.Token.Pos, "required parameter %s follows a parameter with a default value", ident.Value)
+			return false
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	return identifiers
+	return p.expectPeek(token.RPAREN)
 }
 
 // parseCallExpression parses and returns an AST CallExpression Node
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if exp.Arguments != nil {
+		exp.Rparen = p.curToken
+	}
 	return exp
 }
 
-// parseCallArguments parses and returns the Argument Expression Nodes of a CallExpression Node
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
+// parseStringLiteral returns string
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
 
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return args
-	}
+// parseTemplateLiteral parses a string with ${ } interpolations and returns an AST TemplateLiteral node.
+// Eg: "Hello ${name}, you are ${age + 1}"
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	lit := &ast.TemplateLiteral{Token: p.curToken}
+	lit.Parts = append(lit.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
 
-	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.TEMPLATE_MIDDLE) || p.curTokenIs(token.TEMPLATE_TAIL) {
+			p.errorf(p.curToken.Pos, "empty interpolation")
+			return nil
+		}
 
-	for p.peekTokenIs(token.COMMA) {
+		exp := p.parseExpression(LOWEST)
+		if exp == nil {
+			return nil
+		}
+		lit.Parts = append(lit.Parts, exp)
 
-		// Advancing twice to move onto COMMA first, then onto the next argument
-		p.nextToken()
+		if !p.peekTokenIs(token.TEMPLATE_MIDDLE) && !p.peekTokenIs(token.TEMPLATE_TAIL) {
+			p.errorf(p.peekToken.Pos, "expected } to close the interpolation. got %s instead", p.peekToken.Type)
+			return nil
+		}
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
-	}
+		lit.Parts = append(lit.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+		if p.curTokenIs(token.TEMPLATE_TAIL) {
+			return lit
+		}
 	}
-	return args
-
 }
 
-// parseStringLiteral returns string
-func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+// parseIllegal reports source the lexer could not turn into a token, such as a stray '&',
+// an invalid escape sequence or a string missing its closing quote
+func (p *Parser) parseIllegal() ast.Expression {
+	p.errorf(p.curToken.Pos, "illegal token %s", p.curToken.Literal)
+	return nil
 }
 
 // parseArrayLiteral returns an Array
@@ -511,6 +833,9 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements != nil {
+		array.Rbracket = p.curToken
+	}
 
 	return array
 }
@@ -548,20 +873,84 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
-	p.nextToken()
+	// a colon right after the '[' starts a slice with the start omitted
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		exp.Index = p.parseExpression(LOWEST)
+	}
 
-	exp.Index = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
 
 	return exp
 }
 
+// parseOptionalExpression parses left?.[index], left?.[start:stop] and left?.(args),
+// which give null instead of indexing or calling when left is null
+func (p *Parser) parseOptionalExpression(left ast.Expression) ast.Expression {
+	switch {
+	case p.peekTokenIs(token.LBRACKET):
+		p.nextToken()
+		switch exp := p.parseIndexExpression(left).(type) {
+		case *ast.IndexExpression:
+			exp.Optional = true
+			return exp
+		case *ast.SliceExpression:
+			exp.Optional = true
+			return exp
+		}
+		return nil
+
+	case p.peekTokenIs(token.LPAREN):
+		p.nextToken()
+		exp := p.parseCallExpression(left).(*ast.CallExpression)
+		exp.Optional = true
+		return exp
+
+	default:
+		p.errorf(p.peekToken.Pos, "expected [ or ( after ?.. got %s instead", p.peekToken.Type)
+		return nil
+	}
+}
+
+// parseSliceExpression parses the rest of left[start:stop:step] from the first ':', any of the bounds may be omitted
+func (p *Parser) parseSliceExpression(lbracket token.Token, left, start ast.Expression) ast.Expression {
+	slice := &ast.SliceExpression{Token: lbracket, Left: left, Start: start}
+
+	p.nextToken()
+	slice.Stop = p.parseSliceBound()
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice.Step = p.parseSliceBound()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	slice.Rbracket = p.curToken
+
+	return slice
+}
+
+// parseSliceBound parses the bound following a ':' in a slice, returning nil if it is omitted
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
 
 	for !p.peekTokenIs(token.RBRACE) {
 		p.nextToken()
@@ -574,7 +963,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -584,11 +973,168 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
+	hash.Rbrace = p.curToken
 
 	return hash
 }
 
-// parseCommentLiteral stops the parsing process when a comment is encountered
-func (p *Parser) parseCommentLiteral() ast.Expression {
-	return nil
+// parseThrowStatement parses and returns an AST ThrowStatement node
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseTryExpression parses and returns an AST Expression node for try-catch-finally blocks
+// Eg: try { risky() } catch (e) { e["message"] } finally { cleanup() }
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		// The parameter binding the caught error is optional
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			expression.CatchParam = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		p.errorf(p.peekToken.Pos, "expected catch or finally after try block")
+		return nil
+	}
+
+	return expression
+}
+
+// parseWhileStatement parses and returns an AST WhileStatement node
+// Eg: while (x < 10) { puts(x) }
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseForStatement parses and returns an AST ForStatement node
+// Eg: for (x in [1, 2, 3]) { puts(x) }
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseLoopBody parses the block of a loop, in which break and continue are allowed
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return body
+}
+
+// parseLoopControlStatement parses and returns a break or continue statement
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.loopDepth == 0 {
+		p.errorf(tok.Pos, "%s outside of a loop", tok.Literal)
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
 }
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/anirudhlakkaraju/go-interpreter/interpreter/lexing/src/monkey/lexer"
@@ -138,6 +139,43 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5;", 1.5},
+		{"0.125", 0.125},
+		{"2e3", 2000},
+		{"6.5E-2", 0.065},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not an ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -197,6 +235,12 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ** 5;", 5, "**", 5},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
 	}
 
 	for _, tt := range infixTests {
@@ -346,6 +390,70 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a || b && c || d",
+			"((a || (b && c)) || d)",
+		},
+		{
+			"a < b && c >= d || !e",
+			"(((a < b) && (c >= d)) || (!e))",
+		},
+		{
+			"a == b && c != d",
+			"((a == b) && (c != d))",
+		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2 * 3",
+			"((-(2 ** 2)) * 3)",
+		},
+		{
+			"a ** -b",
+			"(a ** (-b))",
+		},
+		{
+			"x = a || b",
+			"x = (a || b)",
+		},
+		{
+			"x = y + 1 * 2",
+			"x = (y + (1 * 2))",
+		},
+		{
+			"a = b = c == d",
+			"a = b = (c == d)",
+		},
+		{
+			"arr[i + 1] *= 2",
+			"(arr[(i + 1)]) *= 2",
+		},
+		{
+			"h[k] /= x -= 1",
+			"(h[k]) /= x -= 1",
+		},
+		{
+			"a ?? b || c ?? d",
+			"((a ?? (b || c)) ?? d)",
+		},
+		{
+			"x = a ?? null",
+			"x = (a ?? null)",
+		},
+		{
+			"-h?.[k]?.[0] + f?.(x, y)",
+			"((-((h?.[k])?.[0])) + f?.(x, y))",
+		},
+		{
+			"s?.[1:] ?? g()?.(1)",
+			"((s?.[1:]) ?? g()?.(1))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -568,6 +676,226 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestDefaultAndRestParameterParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x, y = 10) {};", "fn(x, y = 10) "},
+		{"fn(x = 1 + 2, y = x) {};", "fn(x = (1 + 2), y = x) "},
+		{"fn(...args) {};", "fn(...args) "},
+		{"fn(x, y = 2, ...rest) { x };", "fn(x, y = 2, ...rest) x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.FunctionLiteral. got=%T", stmt.Expression)
+		}
+
+		if function.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, function.String())
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d\n", len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n", len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T", macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+
+	if macro.String() != "macro(x, y) (x + y)" {
+		t.Errorf("macro.String() wrong. got=%q", macro.String())
+	}
+}
+
+func TestInvalidParameterLists(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x = 1, y) {};", "1:11: required parameter y follows a parameter with a default value"},
+		{"fn(...rest, x) {};", "1:11: expected next token to be ). got , instead"},
+		{"fn(1) {};", "1:4: expected next token to be IDENT. got INT instead"},
+		{"macro(x = 1) {};", "1:9: expected next token to be ,. got = instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2", "1:1: cannot assign to 1"},
+		{"1.5 = 2", "1:1: cannot assign to 1.5"},
+		{"f() += 1", "1:1: cannot assign to f()"},
+		{"x + y = 3", "1:1: cannot assign to (x + y)"},
+		{"a[1:2] = 3", "1:1: cannot assign to (a[1:2])"},
+		{"h?.[k] = 3", "1:1: cannot assign to (h?.[k])"},
+		{"null = 1", "1:1: cannot assign to null"},
+		{"h?.k", "1:4: expected [ or ( after ?.. got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestTemplateLiteralParsing(t *testing.T) {
+	input := `"Hello ${name}, you are ${age + 1}"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	lit, ok := stmt.Expression.(*ast.TemplateLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.TemplateLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(lit.Parts) != 5 {
+		t.Fatalf("lit.Parts has wrong length. got=%d", len(lit.Parts))
+	}
+
+	for i, text := range []string{"Hello ", ", you are ", ""} {
+		str, ok := lit.Parts[i*2].(*ast.StringLiteral)
+		if !ok || str.Value != text {
+			t.Errorf("lit.Parts[%d] is not the text %q. got=%T (%s)", i*2, text, lit.Parts[i*2], lit.Parts[i*2])
+		}
+	}
+
+	testIdentifier(t, lit.Parts[1], "name")
+	testInfixExpression(t, lit.Parts[3], "age", "+", 1)
+
+	if lit.String() != `"Hello ${name}, you are ${(age + 1)}"` {
+		t.Errorf("lit.String() wrong. got=%q", lit.String())
+	}
+	if lit.End().Offset != len(input) {
+		t.Errorf("lit.End() wrong. got=%d, want=%d", lit.End().Offset, len(input))
+	}
+}
+
+func TestTemplateLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a ${} b"`, "1:6: empty interpolation"},
+		{`"a ${x y} b"`, "1:8: expected } to close the interpolation. got IDENT instead"},
+		{`"a ${x`, "1:7: expected } to close the interpolation. got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestIllegalTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let s = "a \q";`, `1:12: illegal token \q`},
+		{`x & y`, "1:3: illegal token &"},
+		{`"open`, `1:1: illegal token "open`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5 );"
 
@@ -646,6 +974,74 @@ func TestParsingIndexExpression(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		bounds   [3]bool // whether start, stop and step are given
+	}{
+		{"a[1:2]", "(a[1:2])", [3]bool{true, true, false}},
+		{"a[:2]", "(a[:2])", [3]bool{false, true, false}},
+		{"a[1:]", "(a[1:])", [3]bool{true, false, false}},
+		{"a[:]", "(a[:])", [3]bool{false, false, false}},
+		{"a[::-1]", "(a[::(-1)])", [3]bool{false, false, true}},
+		{"a[1 + 1:n * 2:2]", "(a[(1 + 1):(n * 2):2])", [3]bool{true, true, true}},
+		{"a[:-1][0]", "((a[:(-1)])[0])", [3]bool{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: wrong String(). expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			if index, ok := stmt.Expression.(*ast.IndexExpression); ok {
+				slice, ok = index.Left.(*ast.SliceExpression)
+			}
+			if slice == nil {
+				t.Fatalf("%q: exp not *ast.SliceExpression. got=%T", tt.input, stmt.Expression)
+			}
+		}
+
+		given := [3]bool{slice.Start != nil, slice.Stop != nil, slice.Step != nil}
+		if given != tt.bounds {
+			t.Errorf("%q: wrong bounds given. expected=%v, got=%v", tt.input, tt.bounds, given)
+		}
+	}
+}
+
+func TestInvalidSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[1:2:3:4]", "1:8: expected next token to be ]. got : instead"},
+		{"a[1:2", "1:6: expected next token to be ]. got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
 func TestArrayLiteral(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -692,7 +1088,8 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		"two":   2,
 		"three": 3,
 	}
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -700,6 +1097,10 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		expectedValue := expected[literal.String()]
 		testIntegerLiteral(t, value, expectedValue)
 	}
+
+	if hash.String() != `{one:1, two:2, three:3}` {
+		t.Errorf("pairs are not in source order. got=%q", hash.String())
+	}
 }
 
 func TestParsingEmptyHashLiteral(t *testing.T) {
@@ -744,7 +1145,8 @@ func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		boolean, ok := key.(*ast.Boolean)
 		if !ok {
 			t.Errorf("key is not ast.BooleanLiteral. got=%T", key)
@@ -780,7 +1182,8 @@ func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		integer, ok := key.(*ast.IntegerLiteral)
 		if !ok {
 			t.Errorf("key is not ast.IntegerLiteral. got=%T", key)
@@ -823,7 +1226,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -864,6 +1268,441 @@ func TestCommentLiteralExpression(t *testing.T) {
 		if !testLiteralExpression(t, val, tt.expectedValue) {
 			return
 		}
+
+		if len(program.Statements) != 1 {
+			t.Errorf("comment was parsed into a statement. got=%d statements", len(program.Statements))
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+/* doc */
+let x = 5; // trailing of x
+let f = fn() {
+  // leading of y
+  y /* inside y */ + 1;
+  // dangling in block
+}; /* trailing of f */
+let g = fn(/* no parameters */) { 1 };
+/* dangling */`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	testComments(t, "x leading", program.Statements[0].Comments().Leading, "// leading", "/* doc */")
+	testComments(t, "x trailing", program.Statements[0].Comments().Trailing, "// trailing of x")
+	testComments(t, "f leading", program.Statements[1].Comments().Leading)
+	testComments(t, "f trailing", program.Statements[1].Comments().Trailing, "/* trailing of f */")
+	testComments(t, "program dangling", program.Dangling, "/* dangling */")
+
+	fn := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	testComments(t, "y leading", fn.Body.Statements[0].Comments().Leading, "// leading of y", "/* inside y */")
+	testComments(t, "block dangling", fn.Body.Dangling, "// dangling in block")
+
+	// a comment before a block stays with the statement around it
+	testComments(t, "g leading", program.Statements[2].Comments().Leading, "/* no parameters */")
+	fn = program.Statements[2].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	testComments(t, "g body", fn.Body.Statements[0].Comments().Leading)
+
+	if doc := program.Statements[0].(*ast.LetStatement).Doc(); doc != "leading\ndoc" {
+		t.Errorf("x has wrong doc. got=%q", doc)
+	}
+	if doc := program.Statements[1].(*ast.LetStatement).Doc(); doc != "" {
+		t.Errorf("f has wrong doc. got=%q", doc)
+	}
+
+	if program.String() != "let x = 5;let f = fn() (y + 1);let g = fn() 1;" {
+		t.Errorf("comments changed program.String(). got=%q", program.String())
+	}
+}
+
+func testComments(t *testing.T, name string, comments []*ast.Comment, expected ...string) {
+	t.Helper()
+
+	got := []string{}
+	for _, comment := range comments {
+		got = append(got, comment.String())
+	}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("%s comments wrong. got=%q, want=%q", name, got, expected)
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	input := `try { x } catch (err) { y } finally { z }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.TryExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.TryExpression. got=%T", stmt.Expression)
+	}
+
+	tests := []struct {
+		block    *ast.BlockStatement
+		expected string
+	}{
+		{exp.Block, "x"},
+		{exp.Catch, "y"},
+		{exp.Finally, "z"},
+	}
+
+	for _, tt := range tests {
+		if tt.block == nil || len(tt.block.Statements) != 1 {
+			t.Fatalf("block is not 1 statement. got=%+v", tt.block)
+		}
+
+		body := tt.block.Statements[0].(*ast.ExpressionStatement)
+		testIdentifier(t, body.Expression, tt.expected)
+	}
+
+	if exp.CatchParam == nil || exp.CatchParam.Value != "err" {
+		t.Errorf("exp.CatchParam is not 'err'. got=%+v", exp.CatchParam)
+	}
+}
+
+func TestTryExpressionWithoutHandler(t *testing.T) {
+	l := lexer.New("try { x }")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error. got=%d (%v)", len(errors), errors)
+	}
+
+	expected := "1:10: expected catch or finally after try block"
+	if errors[0].Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0].Error())
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw "bad"; throw x`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	for _, stmt := range program.Statements {
+		throwStmt, ok := stmt.(*ast.ThrowStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ThrowStatement. got=%T", stmt)
+		}
+		if throwStmt.TokenLiteral() != "throw" {
+			t.Errorf("throwStmt.TokenLiteral not 'throw', got %q", throwStmt.TokenLiteral())
+		}
+	}
+
+	testIdentifier(t, program.Statements[1].(*ast.ThrowStatement).Value, "x")
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { if (x == 5) { break; } continue; };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	testInfixExpression(t, stmt.Condition, "x", "<", 10)
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body does not contain 2 statements. got=%d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("body.Statements[1] not *ast.ContinueStatement. got=%T", stmt.Body.Statements[1])
+	}
+
+	expected := "while(x < 10) if(x == 5) break;continue;"
+	if stmt.String() != expected {
+		t.Errorf("stmt.String() wrong. expected=%q, got=%q", expected, stmt.String())
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := `for (item in items) { puts(item); }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.ForStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Variable.Value != "item" {
+		t.Errorf("stmt.Variable not 'item'. got=%q", stmt.Variable.Value)
+	}
+	testIdentifier(t, stmt.Iterable, "items")
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("body does not contain 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+
+	if stmt.String() != "for (item in items) puts(item)" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"if (true) { continue; }", "1:13: continue outside of a loop"},
+		{"while (true) { fn() { break; } }", "1:23: break outside of a loop"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("%q: expected parser errors", tt.input)
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(x, y) {
+	x + y;
+};
+add(1, [2, 3][0]);`
+
+	l := lexer.NewFile("add.mk", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	letStmt := program.Statements[0].(*ast.LetStatement)
+	fn := letStmt.Value.(*ast.FunctionLiteral)
+	body := fn.Body.Statements[0].(*ast.ExpressionStatement)
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	index := call.Arguments[1].(*ast.IndexExpression)
+
+	tests := []struct {
+		node        ast.Node
+		expectedPos string
+		expectedEnd string
+	}{
+		{program, "add.mk:1:1", "add.mk:4:18"},
+		{letStmt, "add.mk:1:1", "add.mk:3:2"},
+		{fn, "add.mk:1:11", "add.mk:3:2"},
+		{body, "add.mk:2:2", "add.mk:2:7"},
+		{call, "add.mk:4:1", "add.mk:4:18"},
+		{index, "add.mk:4:8", "add.mk:4:17"},
+	}
+
+	for _, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedPos {
+			t.Errorf("%T Pos wrong. expected=%q, got=%q", tt.node, tt.expectedPos, tt.node.Pos())
+		}
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("%T End wrong. expected=%q, got=%q", tt.node, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	input := `let x = 5;
+let = 10;`
+
+	l := lexer.NewFile("bad.mk", input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+
+	expected := "bad.mk:2:5: expected next token to be IDENT. got = instead"
+	if errors[0].Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0].Error())
+	}
+}
+
+func TestParseErrorFields(t *testing.T) {
+	l := lexer.NewFile("bad.mk", "let x = 5;\nlet = 10;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error. got=%d", len(errors))
+	}
+
+	err := errors[0]
+	if err.Pos.Filename != "bad.mk" || err.Pos.Line != 2 || err.Pos.Column != 5 {
+		t.Errorf("wrong error position. got=%+v", err.Pos)
+	}
+	if err.Msg != "expected next token to be IDENT. got = instead" {
+		t.Errorf("wrong error message. got=%q", err.Msg)
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string // the statements that parsed, with the failed ones left out
+	}{
+		{
+			"let x 5; let y = 10; let 838383;",
+			[]string{
+				"1:7: expected next token to be =. got INT instead",
+				"1:26: expected next token to be IDENT. got INT instead",
+			},
+			"let y = 10;",
+		},
+		{
+			"let x = ; let y = 2; let = 3; z +",
+			[]string{
+				"1:9: no prefix parse function found for ;",
+				"1:26: expected next token to be IDENT. got = instead",
+				"1:34: no prefix parse function found for EOF",
+			},
+			"let y = 2;",
+		},
+		{
+			// errors inside a block don't end the block early
+			"fn(x) { let = 1; x + ; return x }; let y = 2",
+			[]string{
+				"1:13: expected next token to be IDENT. got = instead",
+				"1:22: no prefix parse function found for ;",
+			},
+			"fn(x) return x;let y = 2;",
+		},
+		{
+			"if (x { 1 } let y = 1;",
+			[]string{"1:7: expected next token to be ). got { instead"},
+			"let y = 1;",
+		},
+		{
+			"} let y = 1; )",
+			[]string{
+				"1:1: no prefix parse function found for }",
+				"1:14: no prefix parse function found for )",
+			},
+			"let y = 1;",
+		},
+		{
+			"let a = [1, 2; let b = fn(x { x }; let c = 3",
+			[]string{
+				"1:14: expected next token to be ]. got ; instead",
+				"1:29: expected next token to be ). got { instead",
+			},
+			"let c = 3;",
+		},
+		{
+			"while (x) { break; } break; continue",
+			[]string{
+				"1:22: break outside of a loop",
+				"1:29: continue outside of a loop",
+			},
+			"whilex break;",
+		},
+		{
+			"let f = fn() { 1 ",
+			[]string{"1:18: expected next token to be }. got EOF instead"},
+			"",
+		},
+		{
+			// the missing ) is reported at the same place as the missing operand, so only once
+			"let x = (",
+			[]string{"1:10: no prefix parse function found for EOF"},
+			"",
+		},
+		{
+			// an operator missing its operand fails the whole interpolation, which isn't reported again
+			`let s = "a${1 +}b"; let t = "${-}"; let u = 1`,
+			[]string{
+				"1:16: no prefix parse function found for TEMPLATE_TAIL",
+				"1:33: no prefix parse function found for TEMPLATE_TAIL",
+			},
+			"let u = 1;",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := []string{}
+		for _, err := range p.Errors() {
+			errors = append(errors, err.Error())
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(tt.expectedErrors, "\n") {
+			t.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedErrors, errors)
+		}
+		if program.String() != tt.expectedStatements {
+			t.Errorf("%q: wrong statements. expected=%q, got=%q", tt.input, tt.expectedStatements, program.String())
+		}
+	}
+}
+
+func TestStatementsWithoutSemicolons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5", "let x = 5;"},
+		{"return 5", "return 5;"},
+		{"let x = 1 let y = 2", "let x = 1;let y = 2;"},
+		{"fn() { return 1 }", "fn() return 1;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
 	}
 }
 
@@ -901,8 +1740,8 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	}
 
 	t.Errorf("parser has %d errors", len(errors))
-	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+	for _, err := range errors {
+		t.Errorf("parser error: %q", err.Error())
 	}
 	t.FailNow()
 }